@@ -0,0 +1,43 @@
+package corbado
+
+import (
+	"context"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+)
+
+// ValidateTokenInto validates the given session token like Sessions().ValidateTokenWithContext() but decodes the
+// claims into T, which must embed entities.Claims. Given validators are called after all other checks have passed
+// and can be used to enforce business rules on the custom claims, for example:
+//
+//	type MyClaims struct {
+//		entities.Claims
+//		Tenant string `json:"tenant"`
+//	}
+//
+//	claims, err := corbado.ValidateTokenInto[MyClaims](ctx, sdk.Sessions(), sessionToken)
+func ValidateTokenInto[T any, PT interface {
+	*T
+	entities.CustomClaims
+}](ctx context.Context, sessions session.Session, sessionToken string, validators ...func(claims *T) error) (*T, error) {
+	if err := assert.NotNil(ctx, sessions); err != nil {
+		return nil, err
+	}
+
+	claimsValidators := make([]entities.ClaimsValidator, len(validators))
+	for i, validator := range validators {
+		validator := validator
+		claimsValidators[i] = func(claims entities.CustomClaims) error {
+			return validator((*T)(claims.(PT)))
+		}
+	}
+
+	claims := PT(new(T))
+	if _, err := sessions.ValidateTokenWithClaims(ctx, sessionToken, claims, claimsValidators...); err != nil {
+		return nil, err
+	}
+
+	return (*T)(claims), nil
+}
@@ -10,15 +10,22 @@ import (
 
 	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/corbado/corbado-go/v2/pkg/logger"
 
 	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/internal/lifecycle"
+	"github.com/corbado/corbado-go/v2/pkg/circuitbreaker"
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/ratelimit"
+	"github.com/corbado/corbado-go/v2/pkg/retry"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
 )
 
-func newClient(config *Config) (*api.ClientWithResponses, error) {
-	if err := assert.NotNil(config); err != nil {
+func newClient(config *Config, httpClient *http.Client, tracker *lifecycle.Tracker) (*api.ClientWithResponses, error) {
+	if err := assert.NotNil(config, httpClient, tracker); err != nil {
 		return nil, err
 	}
 
@@ -27,7 +34,9 @@ func newClient(config *Config) (*api.ClientWithResponses, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	// HTTP client must be first so that extra options (e.g. NewLoggingClientOption) can wrap it
 	extraOptions := []api.ClientOption{
+		api.WithHTTPClient(httpClient),
 		api.WithRequestEditorFn(newSDKHeaderEditorFn),
 		api.WithRequestEditorFn(basicAuth.Intercept),
 	}
@@ -36,17 +45,31 @@ func newClient(config *Config) (*api.ClientWithResponses, error) {
 		extraOptions = append(extraOptions, config.ExtraClientOptions...)
 	}
 
+	// Must be last to wrap whatever HTTP client has been configured by the options above (every attempt is
+	// rate limited, retries are traced and tracked as one operation and count as one failure for the circuit breaker)
+	if config.RateLimit != (ratelimit.Config{}) {
+		extraOptions = append(extraOptions, newRateLimitClientOption(config.RateLimit))
+	}
+
+	extraOptions = append(extraOptions, newRetryClientOption(config.RetryPolicy))
+
+	if config.CircuitBreaker != nil {
+		extraOptions = append(extraOptions, newCircuitBreakerClientOption(config.CircuitBreaker))
+	}
+
+	extraOptions = append(
+		extraOptions,
+		newTracingClientOption(tracing.NewTracer(config.TracerProvider), tracing.NewPropagator(config.TracePropagator)),
+		newLifecycleClientOption(tracker),
+	)
+
 	backendServer := config.BackendAPI + "/v2"
 
 	return api.NewClientWithResponses(backendServer, extraOptions...)
 }
 
-type httpRequestDoer interface {
-	Do(req *http.Request) (*http.Response, error)
-}
-
 type loggingClient struct {
-	underlying httpRequestDoer
+	underlying api.HttpRequestDoer
 }
 
 // Do implements HttpRequestDoer and executes HTTP request
@@ -98,15 +121,19 @@ func (l *loggingClient) readBody(rc *io.ReadCloser /* nilable */) (string, error
 	return string(body), nil
 }
 
-// newLoggingClient returns new logging HTTP client
-func newLoggingClient() (*loggingClient, error) {
-	return &loggingClient{&http.Client{}}, nil
+// newLoggingClient returns new logging HTTP client which wraps given HTTP client (nilable)
+func newLoggingClient(underlying api.HttpRequestDoer) (*loggingClient, error) {
+	if underlying == nil {
+		underlying = &http.Client{}
+	}
+
+	return &loggingClient{underlying}, nil
 }
 
-// NewLoggingClientOption enhances HTTP client to log requests/responses
+// NewLoggingClientOption enhances HTTP client to log requests/responses, it wraps the configured HTTP client
 func NewLoggingClientOption() api.ClientOption {
 	return func(c *api.Client) error {
-		client, err := newLoggingClient()
+		client, err := newLoggingClient(c.Client)
 		if err != nil {
 			return err
 		}
@@ -117,6 +144,93 @@ func NewLoggingClientOption() api.ClientOption {
 	}
 }
 
+type lifecycleClient struct {
+	underlying api.HttpRequestDoer
+	tracker    *lifecycle.Tracker
+}
+
+// Do implements HttpRequestDoer and executes HTTP request (if the SDK has not been closed yet)
+func (l *lifecycleClient) Do(req *http.Request) (*http.Response, error) {
+	if err := l.tracker.Acquire(); err != nil {
+		return nil, err
+	}
+	defer l.tracker.Release()
+
+	return l.underlying.Do(req)
+}
+
+// newLifecycleClientOption tracks in-flight HTTP requests so that they can be drained on close
+func newLifecycleClientOption(tracker *lifecycle.Tracker) api.ClientOption {
+	return func(c *api.Client) error {
+		if err := assert.NotNil(c.Client); err != nil {
+			return err
+		}
+
+		c.Client = &lifecycleClient{
+			underlying: c.Client,
+			tracker:    tracker,
+		}
+
+		return nil
+	}
+}
+
+// newRetryClientOption retries requests which failed with a network error or a transient HTTP status code
+func newRetryClientOption(policy retry.Policy) api.ClientOption {
+	return func(c *api.Client) error {
+		client, err := retry.NewClient(c.Client, policy)
+		if err != nil {
+			return err
+		}
+
+		c.Client = client
+
+		return nil
+	}
+}
+
+// newTracingClientOption creates a span for every request (including all attempts)
+func newTracingClientOption(tracer trace.Tracer, propagator propagation.TextMapPropagator) api.ClientOption {
+	return func(c *api.Client) error {
+		client, err := tracing.NewClient(c.Client, tracer, propagator)
+		if err != nil {
+			return err
+		}
+
+		c.Client = client
+
+		return nil
+	}
+}
+
+// newRateLimitClientOption delays requests until the rate and concurrency limits allow them
+func newRateLimitClientOption(config ratelimit.Config) api.ClientOption {
+	return func(c *api.Client) error {
+		client, err := ratelimit.NewClient(c.Client, config)
+		if err != nil {
+			return errors.WithMessage(err, "Invalid RateLimit given")
+		}
+
+		c.Client = client
+
+		return nil
+	}
+}
+
+// newCircuitBreakerClientOption rejects requests to endpoint groups which failed repeatedly
+func newCircuitBreakerClientOption(config *circuitbreaker.Config) api.ClientOption {
+	return func(c *api.Client) error {
+		client, err := circuitbreaker.NewClient(c.Client, config)
+		if err != nil {
+			return errors.WithMessage(err, "Invalid CircuitBreaker given")
+		}
+
+		c.Client = client
+
+		return nil
+	}
+}
+
 func newSDKHeaderEditorFn(_ context.Context, req *http.Request) error {
 	sdk := struct {
 		Name            string `json:"name"`
@@ -0,0 +1,334 @@
+package corbado
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/corbado/corbado-go/v2/pkg/circuitbreaker"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/ratelimit"
+	"github.com/corbado/corbado-go/v2/pkg/retry"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
+)
+
+// recordingTransport records the paths of all requests and passes them to the default transport
+type recordingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (r *recordingTransport) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.paths...)
+}
+
+func newHTTPClientTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if r.URL.Path == "/.well-known/jwks" {
+			_, _ = w.Write([]byte(`{"keys":[]}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"userID":"usr-1","status":"active"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNewSDK_HTTPClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(config *Config, transport http.RoundTripper)
+	}{
+		{
+			name: "HTTPClient",
+			configure: func(config *Config, transport http.RoundTripper) {
+				config.HTTPClient = &http.Client{Transport: transport}
+			},
+		},
+		{
+			name: "HTTPTransport",
+			configure: func(config *Config, transport http.RoundTripper) {
+				config.HTTPTransport = transport
+			},
+		},
+		{
+			name: "HTTPTransport with logging",
+			configure: func(config *Config, transport http.RoundTripper) {
+				config.HTTPTransport = transport
+				config.ExtraClientOptions = []api.ClientOption{NewLoggingClientOption()}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newHTTPClientTestServer(t)
+			transport := &recordingTransport{}
+
+			config, err := NewConfig("pro-12345678", "corbado1_secret", server.URL, server.URL)
+			require.NoError(t, err)
+			test.configure(config, transport)
+
+			sdk, err := NewSDK(config)
+			require.NoError(t, err)
+
+			_, err = sdk.Users().Get(context.Background(), "usr-1")
+			require.NoError(t, err)
+
+			// Loading the JWKS succeeds (but token is invalid)
+			_, err = sdk.Sessions().ValidateToken("invalid")
+			require.Error(t, err)
+
+			assert.Equal(t, []string{"/v2/users/usr-1", "/.well-known/jwks"}, transport.recorded())
+		})
+	}
+}
+
+func TestNewSDK_RetryPolicy(t *testing.T) {
+	var requests int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"httpStatusCode":503,"message":"unavailable","requestData":{"requestID":"req-1","link":""},"runtime":0.1,"error":{"type":"unavailable","links":[]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config, err := NewConfig("pro-12345678", "corbado1_secret", server.URL, server.URL)
+	require.NoError(t, err)
+
+	config.RetryPolicy = retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	sdk, err := NewSDK(config)
+	require.NoError(t, err)
+
+	// GET is retried
+	_, err = sdk.Users().Get(context.Background(), "usr-1")
+	serverErr := AsServerError(err)
+	require.NotNil(t, serverErr)
+	assert.Equal(t, 3, serverErr.Attempts)
+	assert.Contains(t, serverErr.Error(), "attempts=3")
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requests))
+
+	// POST is not retried by default
+	_, err = sdk.Users().CreateActiveByName(context.Background(), "Jane Doe")
+	serverErr = AsServerError(err)
+	require.NotNil(t, serverErr)
+	assert.Equal(t, 1, serverErr.Attempts)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&requests))
+
+	// POST is retried with policy from context
+	policy := config.RetryPolicy
+	policy.RetryNonIdempotent = true
+
+	_, err = sdk.Users().CreateActiveByName(retry.WithPolicy(context.Background(), policy), "Jane Doe")
+	serverErr = AsServerError(err)
+	require.NotNil(t, serverErr)
+	assert.Equal(t, 3, serverErr.Attempts)
+	assert.Equal(t, int64(7), atomic.LoadInt64(&requests))
+}
+
+func TestNewSDK_CircuitBreaker(t *testing.T) {
+	var requests int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"httpStatusCode":500,"message":"internal error","requestData":{"requestID":"req-1","link":""},"runtime":0.1,"error":{"type":"internal","links":[]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config, err := NewConfig("pro-12345678", "corbado1_secret", server.URL, server.URL)
+	require.NoError(t, err)
+
+	config.RetryPolicy = retry.Policy{}
+	config.CircuitBreaker = &circuitbreaker.Config{
+		Default: circuitbreaker.Settings{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+	}
+
+	sdk, err := NewSDK(config)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = sdk.Users().Get(context.Background(), "usr-1")
+		assert.True(t, IsServerError(err))
+		assert.False(t, IsCircuitOpenError(err))
+	}
+
+	_, err = sdk.Users().Get(context.Background(), "usr-1")
+	assert.False(t, IsServerError(err))
+	assert.True(t, IsCircuitOpenError(err))
+	assert.Equal(t, "users", AsCircuitOpenError(err).Group)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&requests))
+}
+
+func TestNewSDK_InvalidCircuitBreaker(t *testing.T) {
+	config, err := NewConfig("pro-12345678", "corbado1_secret", "https://auth.acme.com", "https://backendapi.cloud.corbado.io")
+	require.NoError(t, err)
+
+	config.CircuitBreaker = &circuitbreaker.Config{}
+
+	_, err = NewSDK(config)
+	assert.ErrorContains(t, err, "Invalid CircuitBreaker given")
+}
+
+func TestNewSDK_NilHTTPClient(t *testing.T) {
+	config, err := NewConfig("pro-12345678", "corbado1_secret", "https://auth.acme.com", "https://backendapi.cloud.corbado.io")
+	require.NoError(t, err)
+
+	config.ExtraClientOptions = []api.ClientOption{func(c *api.Client) error {
+		c.Client = nil
+
+		return nil
+	}}
+
+	_, err = NewSDK(config)
+	assert.Error(t, err)
+}
+
+func TestNewSDK_RateLimit(t *testing.T) {
+	var current, maxInFlight int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight := atomic.AddInt64(&current, 1)
+		defer atomic.AddInt64(&current, -1)
+
+		for {
+			observed := atomic.LoadInt64(&maxInFlight)
+			if inFlight <= observed || atomic.CompareAndSwapInt64(&maxInFlight, observed, inFlight) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"userID":"usr-1","status":"active","identifiers":[],"paging":{"page":1,"totalItems":0,"totalPages":0}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config, err := NewConfig("pro-12345678", "corbado1_secret", server.URL, server.URL)
+	require.NoError(t, err)
+
+	config.RateLimit = ratelimit.Config{MaxInFlight: 1}
+
+	sdk, err := NewSDK(config)
+	require.NoError(t, err)
+
+	// Limit is shared by all services
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := sdk.Users().Get(context.Background(), "usr-1")
+			assert.NoError(t, err)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, err := sdk.Identifiers().ListByUserID(context.Background(), "usr-1", "", 1, 10)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&maxInFlight))
+}
+
+func TestNewSDK_InvalidRateLimit(t *testing.T) {
+	config, err := NewConfig("pro-12345678", "corbado1_secret", "https://auth.acme.com", "https://backendapi.cloud.corbado.io")
+	require.NoError(t, err)
+
+	config.RateLimit = ratelimit.Config{MaxInFlight: -1}
+
+	_, err = NewSDK(config)
+	assert.ErrorContains(t, err, "Invalid RateLimit given")
+}
+
+func TestNewSDK_Tracing(t *testing.T) {
+	var requests int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("traceparent"))
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if atomic.AddInt64(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"httpStatusCode":503,"message":"unavailable","requestData":{"requestID":"req-1","link":""},"runtime":0.1,"error":{"type":"unavailable","links":[]}}`))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"httpStatusCode":200,"requestData":{"requestID":"req-2","link":""},"userID":"usr-1","status":"active"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+
+	config, err := NewConfig("pro-12345678", "corbado1_secret", server.URL, server.URL)
+	require.NoError(t, err)
+
+	config.RetryPolicy = retry.Policy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	config.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	config.TracePropagator = propagation.TraceContext{}
+
+	sdk, err := NewSDK(config)
+	require.NoError(t, err)
+
+	_, err = sdk.Users().Get(context.Background(), "usr-1")
+	require.NoError(t, err)
+
+	// One span for all attempts
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "UserGet", spans[0].Name)
+
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+
+	assert.Equal(t, int64(2), attributes[tracing.AttributeAttempts].AsInt64())
+	assert.Equal(t, "req-2", attributes[tracing.AttributeRequestID].AsString())
+	assert.Equal(t, int64(http.StatusOK), attributes["http.status_code"].AsInt64())
+}
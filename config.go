@@ -1,14 +1,21 @@
 package corbado
 
 import (
+	"io/fs"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/circuitbreaker"
+	"github.com/corbado/corbado-go/v2/pkg/denylist"
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/ratelimit"
+	"github.com/corbado/corbado-go/v2/pkg/retry"
 )
 
 type Config struct {
@@ -22,16 +29,127 @@ type Config struct {
 	JWKSRefreshRateLimit time.Duration
 	JWKSRefreshTimeout   time.Duration
 
-	HTTPClient         *http.Client
+	// JWKSEagerLoad loads the JWKS in the background when the SDK is created (instead of on the first
+	// session token validation), use Sessions().WaitReady() to wait for it
+	JWKSEagerLoad bool
+
+	// JWKSFailFast loads the JWKS synchronously when the SDK is created and makes NewSDK fail if that is
+	// not possible
+	JWKSFailFast bool
+
+	// JWTLeeway is the allowed clock skew when validating the exp, nbf and iat claims of session tokens
+	JWTLeeway time.Duration
+
+	// JWTMaxTokenAge rejects session tokens issued (iat) longer ago than the given duration, zero disables
+	// the check
+	JWTMaxTokenAge time.Duration
+
+	// Clock returns the current time used for session token validation, defaults to time.Now (useful for
+	// deterministic tests)
+	Clock func() time.Time
+
+	// JWTAlgorithms are the allowed signing algorithms of session tokens (RS256 by default)
+	JWTAlgorithms []string
+
+	// JWKSPinnedKeyIDs restricts the accepted signing keys to the given key IDs (kid), empty allows all keys
+	// of the JWKS
+	JWKSPinnedKeyIDs []string
+
+	// JWKSPinnedKeyThumbprints restricts the accepted signing keys to the given JWK thumbprints (RFC 7638,
+	// SHA-256, base64url encoded), empty allows all keys of the JWKS
+	JWKSPinnedKeyThumbprints []string
+
+	// JWTIssuers are accepted as session token issuers in addition to FrontendAPI (exact match), e.g. if the
+	// project is served under several CNAMEs
+	JWTIssuers []string
+
+	// JWTIssuerPatterns are host patterns (see path.Match, e.g. "*.acme.com") of additionally accepted session
+	// token issuers, only HTTPS issuers can match
+	JWTIssuerPatterns []string
+
+	// DisableLegacyJWTIssuers stops accepting the built-in Frontend API hostnames as session token issuers
+	// (https://<project ID>.frontendapi.corbado.io and https://<project ID>.frontendapi.cloud.corbado.io)
+	DisableLegacyJWTIssuers bool
+
+	// JWKS is a static JWKS (JSON) used to validate session tokens instead of fetching it from the Frontend API,
+	// e.g. for air-gapped or test deployments
+	JWKS []byte
+
+	// JWKSFile is the path of a JWKS file (JSON) used to validate session tokens instead of fetching it from the
+	// Frontend API. The path is relative to JWKSFS if given. The file is re-read every JWKSFilePollInterval so
+	// that key rotations are picked up.
+	JWKSFile             string
+	JWKSFS               fs.FS
+	JWKSFilePollInterval time.Duration
+
+	// JWKSCacheFile is the path of a file the last successfully fetched JWKS (including fetch timestamp and ETag)
+	// is persisted to. If fetching the JWKS fails on startup the cached JWKS is used instead as long as it is not
+	// older than JWKSCacheMaxStaleness, fetching is then retried with backoff (up to JWKSRefreshRateLimit) until
+	// it succeeds.
+	JWKSCacheFile         string
+	JWKSCacheMaxStaleness time.Duration
+
+	// JWTCacheSize is the maximum number of validated session tokens which are cached (until they expire or the
+	// JWKS changes) to skip parsing and signature verification on hot paths, zero disables the cache. See
+	// Sessions().TokenCacheStats() for the hit rate.
+	JWTCacheSize int
+
+	// JWTDenylist is consulted for every otherwise valid session token to reject revoked tokens (e.g. after a
+	// logout), see denylist.NewMemory for an in-memory implementation. Nil disables the denylist.
+	JWTDenylist denylist.Store
+
+	// StrictValidationCacheTTL is the time the results of the online checks of Sessions().ValidateTokenStrict()
+	// (user and long session status) are cached, zero disables caching. At most 10000 results are cached, the
+	// oldest one is evicted first.
+	StrictValidationCacheTTL time.Duration
+
+	// JWTIncludeTokenInErrors sets the raw session token in validation errors, by default only a fingerprint is
+	// contained. It should only be enabled for debugging because tokens end up in logs and error trackers.
+	JWTIncludeTokenInErrors bool
+
+	// HTTPClient is used for all requests (Backend API and JWKS), e.g. to configure proxies, mTLS or timeouts.
+	// Alternatively only HTTPTransport can be given.
+	HTTPClient    *http.Client
+	HTTPTransport http.RoundTripper
+
+	// RetryPolicy configures automatic retries of Backend API requests which failed with a network error or a
+	// transient HTTP status code. By default only idempotent requests are retried, use retry.WithPolicy to
+	// override the policy for a single call.
+	RetryPolicy retry.Policy
+
+	// CircuitBreaker rejects Backend API requests right away (see IsCircuitOpenError) after repeated failures of
+	// the same endpoint group instead of waiting for timeouts, e.g. during an incident. Nil disables the circuit
+	// breaker, see circuitbreaker.DefaultConfig for sane defaults.
+	CircuitBreaker *circuitbreaker.Config
+
+	// RateLimit limits the rate (token bucket) and concurrency of Backend API requests on the client side, e.g. for
+	// batch jobs which would exceed the rate limits of the Backend API otherwise. The limits are shared by all
+	// services of an SDK instance, the zero value disables them.
+	RateLimit ratelimit.Config
+
+	// TracerProvider is used to create OpenTelemetry spans for Backend API requests and session token validations,
+	// defaults to the global tracer provider (see otel.SetTracerProvider)
+	TracerProvider trace.TracerProvider
+
+	// TracePropagator injects the trace context into Backend API requests, defaults to the global propagator (see
+	// otel.SetTextMapPropagator)
+	TracePropagator propagation.TextMapPropagator
+
 	ExtraClientOptions []api.ClientOption
 }
 
 const (
 	configDefaultCacheMaxAge = time.Minute
 
-	configDefaultJWKSRefreshInterval  = time.Hour
-	configDefaultJWKSRefreshRateLimit = 5 * time.Minute
-	configDefaultJWKSRefreshTimeout   = 10 * time.Second
+	configDefaultJWKSRefreshInterval   = time.Hour
+	configDefaultJWKSRefreshRateLimit  = 5 * time.Minute
+	configDefaultJWKSRefreshTimeout    = 10 * time.Second
+	configDefaultJWKSFilePollInterval  = 5 * time.Second
+	configDefaultJWKSCacheMaxStaleness = 24 * time.Hour
+
+	configDefaultStrictValidationCacheTTL = 10 * time.Second
+
+	configDefaultJWTAlgorithm = "RS256"
 )
 
 // NewConfig returns new config with sane defaults
@@ -53,14 +171,19 @@ func NewConfig(projectID string, apiSecret string, frontendAPI string, backendAP
 	}
 
 	return &Config{
-		ProjectID:            projectID,
-		APISecret:            apiSecret,
-		FrontendAPI:          frontendAPI,
-		BackendAPI:           backendAPI,
-		CacheMaxAge:          configDefaultCacheMaxAge,
-		JWKSRefreshInterval:  configDefaultJWKSRefreshInterval,
-		JWKSRefreshRateLimit: configDefaultJWKSRefreshRateLimit,
-		JWKSRefreshTimeout:   configDefaultJWKSRefreshTimeout,
+		ProjectID:                projectID,
+		APISecret:                apiSecret,
+		FrontendAPI:              frontendAPI,
+		BackendAPI:               backendAPI,
+		CacheMaxAge:              configDefaultCacheMaxAge,
+		JWKSRefreshInterval:      configDefaultJWKSRefreshInterval,
+		JWKSRefreshRateLimit:     configDefaultJWKSRefreshRateLimit,
+		JWKSRefreshTimeout:       configDefaultJWKSRefreshTimeout,
+		JWTAlgorithms:            []string{configDefaultJWTAlgorithm},
+		JWKSFilePollInterval:     configDefaultJWKSFilePollInterval,
+		JWKSCacheMaxStaleness:    configDefaultJWKSCacheMaxStaleness,
+		StrictValidationCacheTTL: configDefaultStrictValidationCacheTTL,
+		RetryPolicy:              retry.DefaultPolicy(),
 	}, nil
 }
 
@@ -108,5 +231,71 @@ func (c *Config) validate() error {
 		return errors.WithMessage(err, "Invalid JWKSRefreshTimeout given")
 	}
 
+	if err := assert.DurationNotNegative(c.JWTLeeway); err != nil {
+		return errors.WithMessage(err, "Invalid JWTLeeway given")
+	}
+
+	if err := assert.DurationNotNegative(c.JWTMaxTokenAge); err != nil {
+		return errors.WithMessage(err, "Invalid JWTMaxTokenAge given")
+	}
+
+	if len(c.JWKS) > 0 && c.JWKSFile != "" {
+		return errors.New("Invalid JWKS given: JWKS and JWKSFile must not be given both")
+	}
+
+	if c.JWKSFS != nil && c.JWKSFile == "" {
+		return errors.New("Invalid JWKSFile given: JWKSFile is required if JWKSFS is given")
+	}
+
+	if err := assert.DurationNotNegative(c.JWKSFilePollInterval); err != nil {
+		return errors.WithMessage(err, "Invalid JWKSFilePollInterval given")
+	}
+
+	if c.JWKSCacheFile != "" {
+		if err := assert.DurationNotEmpty(c.JWKSCacheMaxStaleness); err != nil {
+			return errors.WithMessage(err, "Invalid JWKSCacheMaxStaleness given")
+		}
+	}
+
+	if err := assert.DurationNotNegative(c.StrictValidationCacheTTL); err != nil {
+		return errors.WithMessage(err, "Invalid StrictValidationCacheTTL given")
+	}
+
+	if err := assert.IntNotNegative(c.JWTCacheSize); err != nil {
+		return errors.WithMessage(err, "Invalid JWTCacheSize given")
+	}
+
+	if err := assert.IntNotNegative(c.RetryPolicy.MaxAttempts); err != nil {
+		return errors.WithMessage(err, "Invalid RetryPolicy given")
+	}
+
+	if err := assert.DurationNotNegative(c.RetryPolicy.InitialBackoff); err != nil {
+		return errors.WithMessage(err, "Invalid RetryPolicy given")
+	}
+
+	if err := assert.DurationNotNegative(c.RetryPolicy.MaxBackoff); err != nil {
+		return errors.WithMessage(err, "Invalid RetryPolicy given")
+	}
+
+	if c.HTTPClient != nil && c.HTTPTransport != nil {
+		return errors.New("Invalid HTTPTransport given: HTTPClient and HTTPTransport must not be given both")
+	}
+
+	for _, pattern := range c.JWTIssuerPatterns {
+		if err := assert.ValidHostPattern(pattern); err != nil {
+			return errors.WithMessage(err, "Invalid JWTIssuerPatterns given")
+		}
+	}
+
 	return nil
 }
+
+// httpClient returns the HTTP client to be used for all requests (HTTPClient, a client using HTTPTransport or a
+// default client)
+func (c *Config) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return &http.Client{Transport: c.HTTPTransport}
+}
@@ -1,12 +1,15 @@
 package corbado
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/retry"
 )
 
 func TestNewConfig_Success(t *testing.T) {
@@ -26,6 +29,10 @@ func TestNewConfig_Success(t *testing.T) {
 	assert.Equal(t, configDefaultJWKSRefreshInterval, cfg.JWKSRefreshInterval)
 	assert.Equal(t, configDefaultJWKSRefreshRateLimit, cfg.JWKSRefreshRateLimit)
 	assert.Equal(t, configDefaultJWKSRefreshTimeout, cfg.JWKSRefreshTimeout)
+	assert.Equal(t, []string{configDefaultJWTAlgorithm}, cfg.JWTAlgorithms)
+	assert.Equal(t, configDefaultJWKSFilePollInterval, cfg.JWKSFilePollInterval)
+	assert.Equal(t, configDefaultJWKSCacheMaxStaleness, cfg.JWKSCacheMaxStaleness)
+	assert.Equal(t, configDefaultStrictValidationCacheTTL, cfg.StrictValidationCacheTTL)
 }
 
 func TestNewConfig_Failure(t *testing.T) {
@@ -166,6 +173,113 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErrorContains: "Invalid JWKSRefreshTimeout given",
 		},
+		{
+			name: "invalid JWTLeeway",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				JWTLeeway:            -time.Second,
+			},
+			expectedErrorContains: "Invalid JWTLeeway given",
+		},
+		{
+			name: "invalid JWTIssuerPatterns",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				JWTIssuerPatterns:    []string{"[acme.com"},
+			},
+			expectedErrorContains: "Invalid JWTIssuerPatterns given",
+		},
+		{
+			name: "invalid JWKS",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				JWKS:                 []byte(`{"keys":[]}`),
+				JWKSFile:             "jwks.json",
+			},
+			expectedErrorContains: "Invalid JWKS given",
+		},
+		{
+			name: "invalid JWKSCacheMaxStaleness",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				JWKSCacheFile:        "jwks-cache.json",
+			},
+			expectedErrorContains: "Invalid JWKSCacheMaxStaleness given",
+		},
+		{
+			name: "invalid JWTCacheSize",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				JWTCacheSize:         -1,
+			},
+			expectedErrorContains: "Invalid JWTCacheSize given",
+		},
+		{
+			name: "invalid HTTPTransport",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				HTTPClient:           &http.Client{},
+				HTTPTransport:        http.DefaultTransport,
+			},
+			expectedErrorContains: "Invalid HTTPTransport given",
+		},
+		{
+			name: "invalid RetryPolicy",
+			config: Config{
+				ProjectID:            "pro-12345678",
+				APISecret:            "corbado1_secret",
+				FrontendAPI:          "http://localhost:8080",
+				BackendAPI:           "http://localhost:9090",
+				CacheMaxAge:          10 * time.Second,
+				JWKSRefreshInterval:  10 * time.Second,
+				JWKSRefreshRateLimit: 10 * time.Second,
+				JWKSRefreshTimeout:   10 * time.Second,
+				RetryPolicy:          retry.Policy{MaxAttempts: 3, InitialBackoff: -1},
+			},
+			expectedErrorContains: "Invalid RetryPolicy given",
+		},
 	}
 
 	for _, test := range tests {
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -83,6 +84,8 @@ func main() {
 
 			fmt.Fprintf(w, "User ID: %s\n", user.UserID)
 			fmt.Fprintf(w, "User full name: %s\n", user.FullName)
+			fmt.Fprintf(w, "Logged in as: %s\n", user.LoginIdentifier)
+			fmt.Fprintf(w, "Session token expires in: %s\n", user.RemainingLifetime(time.Now()))
 		}
 
 		//////////////////////////////////////////////////////////////////////////////////////////////
@@ -3,7 +3,9 @@ package assert
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -149,3 +151,38 @@ func DurationNotEmpty(value time.Duration) error {
 
 	return nil
 }
+
+// DurationNotNegative checks if given duration is not negative
+func DurationNotNegative(value time.Duration) error {
+	if value < 0 {
+		return errors.Errorf("assert failed: given value '%s' is negative", value)
+	}
+
+	return nil
+}
+
+// IntNotNegative checks if given int is not negative
+func IntNotNegative(value int) error {
+	if value < 0 {
+		return errors.Errorf("assert failed: given value %d is negative", value)
+	}
+
+	return nil
+}
+
+// ValidHostPattern checks if given string is a valid host pattern (see path.Match)
+func ValidHostPattern(value string) error {
+	if err := StringNotEmpty(value); err != nil {
+		return err
+	}
+
+	if _, err := path.Match(value, ""); err != nil {
+		return errors.Errorf("assert failed: given value '%s' is not a valid pattern (%s)", value, err.Error())
+	}
+
+	if strings.Contains(value, "/") {
+		return errors.Errorf("assert failed: given value '%s' must only match the host", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrClosed is returned by all operations started after the SDK has been closed
+var ErrClosed = errors.New("SDK closed")
+
+// Tracker tracks in-flight operations so that they can be drained on close
+type Tracker struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{}
+}
+
+// NewTracker returns new tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		drained: make(chan struct{}),
+	}
+}
+
+// Acquire registers a new in-flight operation, it returns ErrClosed if the tracker has been closed already.
+// Every successful Acquire must be followed by a Release.
+func (t *Tracker) Acquire() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return errors.WithStack(ErrClosed)
+	}
+
+	t.inflight++
+
+	return nil
+}
+
+// Release marks an in-flight operation as finished
+func (t *Tracker) Release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inflight--
+	if t.closed && t.inflight == 0 {
+		t.signalDrained()
+	}
+}
+
+// Close rejects all new operations and blocks until all in-flight operations have finished or the given
+// context is done
+func (t *Tracker) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+
+		if t.inflight == 0 {
+			t.signalDrained()
+		}
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.drained:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// signalDrained closes the drained channel (must be called with mu held)
+func (t *Tracker) signalDrained() {
+	select {
+	case <-t.drained:
+	default:
+		close(t.drained)
+	}
+}
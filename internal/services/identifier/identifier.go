@@ -10,6 +10,7 @@ import (
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
 	"github.com/corbado/corbado-go/v2/pkg/generated/common"
 	"github.com/corbado/corbado-go/v2/pkg/servererror"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
 )
 
 type Identifier interface {
@@ -47,13 +48,13 @@ func (i *Impl) Create(
 	req api.IdentifierCreateReq,
 	editors ...api.RequestEditorFn,
 ) (*api.Identifier, error) {
-	res, err := i.client.IdentifierCreateWithResponse(ctx, userID, req, editors...)
+	res, err := i.client.IdentifierCreateWithResponse(tracing.WithOperation(ctx, "IdentifierCreate"), userID, req, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -66,13 +67,13 @@ func (i *Impl) Delete(
 	identifierID string,
 	editors ...api.RequestEditorFn,
 ) (*common.GenericRsp, error) {
-	res, err := i.client.IdentifierDeleteWithResponse(ctx, userID, identifierID, editors...)
+	res, err := i.client.IdentifierDeleteWithResponse(tracing.WithOperation(ctx, "IdentifierDelete"), userID, identifierID, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -105,13 +106,13 @@ func (i *Impl) List(
 		req.PageSize = &pageSize
 	}
 
-	res, err := i.client.IdentifierListWithResponse(ctx, &req, editors...)
+	res, err := i.client.IdentifierListWithResponse(tracing.WithOperation(ctx, "IdentifierList"), &req, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -171,13 +172,13 @@ func (i *Impl) UpdateIdentifier(
 	req api.IdentifierUpdateReq,
 	editors ...api.RequestEditorFn,
 ) (*api.Identifier, error) {
-	res, err := i.client.IdentifierUpdateWithResponse(ctx, userID, identifierID, req, editors...)
+	res, err := i.client.IdentifierUpdateWithResponse(tracing.WithOperation(ctx, "IdentifierUpdate"), userID, identifierID, req, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jwksCache persists the last successfully fetched JWKS to disk so that session tokens can be validated even if
+// the JWKS cannot be fetched on startup
+type jwksCache struct {
+	path string
+}
+
+type jwksCacheEntry struct {
+	FetchedAt time.Time       `json:"fetchedAt"`
+	ETag      string          `json:"etag,omitempty"`
+	JWKS      json.RawMessage `json:"jwks"`
+}
+
+// load reads the cache entry, it returns nil if there is none
+func (c *jwksCache) load() (*jwksCacheEntry, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var entry jwksCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &entry, nil
+}
+
+// store writes the cache entry atomically (write to temporary file and rename)
+func (c *jwksCache) store(entry *jwksCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+
+		return errors.WithStack(err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Rename(tmpFile.Name(), c.path))
+}
@@ -1,11 +1,18 @@
 package session
 
 import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/denylist"
 )
 
 type Config struct {
@@ -15,6 +22,78 @@ type Config struct {
 	JWKSRefreshInterval  time.Duration
 	JWKSRefreshRateLimit time.Duration
 	JWKSRefreshTimeout   time.Duration
+
+	// JWKSEagerLoad loads the JWKS in the background right away (instead of on the first validation)
+	JWKSEagerLoad bool
+
+	// JWKSFailFast loads the JWKS synchronously in New and fails if that is not possible
+	JWKSFailFast bool
+
+	// Leeway is the allowed clock skew when validating exp, nbf and iat
+	Leeway time.Duration
+
+	// MaxTokenAge rejects tokens issued (iat) longer ago than the given duration, zero disables the check
+	MaxTokenAge time.Duration
+
+	// Clock returns the current time, defaults to time.Now
+	Clock func() time.Time
+
+	// Algorithms are the allowed signing algorithms, defaults to RS256
+	Algorithms []string
+
+	// PinnedKeyIDs restricts the accepted signing keys to the given key IDs (kid), empty allows all keys
+	PinnedKeyIDs []string
+
+	// PinnedKeyThumbprints restricts the accepted signing keys to the given JWK thumbprints (RFC 7638, SHA-256,
+	// base64url encoded), empty allows all keys
+	PinnedKeyThumbprints []string
+
+	// Issuers are accepted in addition to JWTIssuer (exact match)
+	Issuers []string
+
+	// IssuerPatterns are host patterns (see path.Match, e.g. "*.acme.com") of additionally accepted issuers, only
+	// HTTPS issuers can match
+	IssuerPatterns []string
+
+	// DisableLegacyIssuers stops accepting the built-in Frontend API issuers (https://<project ID>.frontendapi.corbado.io
+	// and https://<project ID>.frontendapi.cloud.corbado.io), only JWTIssuer, Issuers and IssuerPatterns are accepted
+	DisableLegacyIssuers bool
+
+	// JWKS is a static JWKS (JSON) used instead of fetching it from JwksURI
+	JWKS []byte
+
+	// JWKSFile is the path of a JWKS file (JSON) used instead of fetching it from JwksURI, the path is relative
+	// to JWKSFS if given. The file is re-read every JWKSFilePollInterval.
+	JWKSFile             string
+	JWKSFS               fs.FS
+	JWKSFilePollInterval time.Duration
+
+	// JWKSCacheFile is the path of a file the last successfully fetched JWKS is stored in, it is used if the
+	// JWKS cannot be fetched on startup (as long as it is not older than JWKSCacheMaxStaleness)
+	JWKSCacheFile         string
+	JWKSCacheMaxStaleness time.Duration
+
+	// TokenCacheSize is the maximum number of validated session tokens which are cached (until they expire or the
+	// JWKS changes) to skip parsing and signature verification, zero disables the cache
+	TokenCacheSize int
+
+	// Denylist is consulted for every otherwise valid session token (also for cached ones), nil disables it
+	Denylist denylist.Store
+
+	// StrictCacheTTL is the time the results of online checks (see ValidateTokenStrict) are cached, zero disables
+	// caching
+	StrictCacheTTL time.Duration
+
+	// IncludeTokenInErrors sets the raw session token in validation errors (see validationerror.ValidationError),
+	// it should only be enabled for debugging because tokens end up in logs and error trackers
+	IncludeTokenInErrors bool
+
+	// HTTPClient is used to fetch the JWKS (the same client as for the Backend API), defaults to
+	// http.DefaultClient
+	HTTPClient *http.Client
+
+	// TracerProvider is used to create spans for session token validations, defaults to the global one
+	TracerProvider trace.TracerProvider
 }
 
 func (c *Config) validate() error {
@@ -26,8 +105,18 @@ func (c *Config) validate() error {
 		return errors.WithMessage(err, "Invalid JWTIssuer given")
 	}
 
-	if err := assert.StringNotEmpty(c.JwksURI); err != nil {
-		return errors.WithMessage(err, "Invalid JwksURI given")
+	if len(c.JWKS) > 0 && c.JWKSFile != "" {
+		return errors.New("Invalid JWKS given: JWKS and JWKSFile must not be given both")
+	}
+
+	if c.JWKSFS != nil && c.JWKSFile == "" {
+		return errors.New("Invalid JWKSFile given: JWKSFile is required if JWKSFS is given")
+	}
+
+	if len(c.JWKS) == 0 && c.JWKSFile == "" {
+		if err := assert.StringNotEmpty(c.JwksURI); err != nil {
+			return errors.WithMessage(err, "Invalid JwksURI given")
+		}
 	}
 
 	if err := assert.DurationNotEmpty(c.JWKSRefreshInterval); err != nil {
@@ -42,5 +131,87 @@ func (c *Config) validate() error {
 		return errors.WithMessage(err, "Invalid JWKSRefreshTimeout given")
 	}
 
+	if err := assert.DurationNotNegative(c.Leeway); err != nil {
+		return errors.WithMessage(err, "Invalid Leeway given")
+	}
+
+	if err := assert.DurationNotNegative(c.MaxTokenAge); err != nil {
+		return errors.WithMessage(err, "Invalid MaxTokenAge given")
+	}
+
+	if err := assert.DurationNotNegative(c.JWKSFilePollInterval); err != nil {
+		return errors.WithMessage(err, "Invalid JWKSFilePollInterval given")
+	}
+
+	if c.JWKSCacheFile != "" {
+		if err := assert.DurationNotEmpty(c.JWKSCacheMaxStaleness); err != nil {
+			return errors.WithMessage(err, "Invalid JWKSCacheMaxStaleness given")
+		}
+	}
+
+	if err := assert.DurationNotNegative(c.StrictCacheTTL); err != nil {
+		return errors.WithMessage(err, "Invalid StrictCacheTTL given")
+	}
+
+	if err := assert.IntNotNegative(c.TokenCacheSize); err != nil {
+		return errors.WithMessage(err, "Invalid TokenCacheSize given")
+	}
+
+	for _, pattern := range c.IssuerPatterns {
+		if err := assert.ValidHostPattern(pattern); err != nil {
+			return errors.WithMessage(err, "Invalid IssuerPatterns given")
+		}
+	}
+
 	return nil
 }
+
+// AcceptedIssuers returns all issuers which are accepted by exact match (see AcceptsIssuer)
+func (c *Config) AcceptedIssuers() []string {
+	issuers := make([]string, 0, len(c.Issuers)+3)
+
+	if !c.DisableLegacyIssuers {
+		// Old Frontend API (without .cloud.) to make our Frontend API host name change downwards compatible and
+		// new Frontend API (with .cloud.)
+		issuers = append(issuers,
+			fmt.Sprintf("https://%s.frontendapi.corbado.io", c.ProjectID),
+			fmt.Sprintf("https://%s.frontendapi.cloud.corbado.io", c.ProjectID),
+		)
+	}
+
+	// Configured issuer (from FrontendAPI), needed if you set a CNAME for example, and additionally configured
+	// issuers (e.g. multiple CNAMEs)
+	issuers = append(issuers, c.JWTIssuer)
+
+	return append(issuers, c.Issuers...)
+}
+
+// AcceptsIssuer returns true if given issuer is accepted (exact match or issuer pattern)
+func (c *Config) AcceptsIssuer(jwtIssuer string) bool {
+	if contains(c.AcceptedIssuers(), jwtIssuer) {
+		return true
+	}
+
+	return len(c.IssuerPatterns) > 0 && matchesIssuerPattern(c.IssuerPatterns, jwtIssuer)
+}
+
+// matchesIssuerPattern returns true if the host of given issuer matches one of the given patterns (see path.Match),
+// the issuer must be an HTTPS URL without path, query and fragment
+func matchesIssuerPattern(patterns []string, jwtIssuer string) bool {
+	if err := assert.ValidAPIEndpoint(jwtIssuer); err != nil {
+		return false
+	}
+
+	issuerURL, err := url.Parse(jwtIssuer)
+	if err != nil || issuerURL.Scheme != "https" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, issuerURL.Host); matched {
+			return true
+		}
+	}
+
+	return false
+}
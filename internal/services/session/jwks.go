@@ -0,0 +1,576 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/logger"
+)
+
+const (
+	keySetEagerLoadMinBackoff = time.Second
+)
+
+// keySet holds the JWKS used to verify session tokens and keeps it up to date. All fetches are bound to a
+// context so that callers of ValidateTokenWithContext can cancel them.
+type keySet struct {
+	config *Config
+	source jwksSource
+	cache  *jwksCache // nilable
+
+	// onChange is called whenever the JWKS has been replaced (must be set before the key set is used)
+	onChange func()
+
+	mu          sync.RWMutex
+	jwks        *keyfunc.JWKS
+	raw         []byte
+	keys        []entities.KeyStatus
+	lastRefresh time.Time
+	lastAttempt time.Time
+	nextRefresh time.Time
+	lastErr     error
+	lastErrAt   time.Time
+
+	// ready is closed as soon as the JWKS has been loaded successfully for the first time
+	ready chan struct{}
+
+	// loadSem and refreshSem serialize loads and refreshes, they are channels (and not mutexes) so that
+	// waiting can be cancelled
+	loadSem    chan struct{}
+	refreshSem chan struct{}
+
+	// ctx is cancelled on close and ends all background goroutines (tracked by wg)
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newKeySet(config *Config) *keySet {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var cache *jwksCache
+	if config.JWKSCacheFile != "" {
+		cache = &jwksCache{path: config.JWKSCacheFile}
+	}
+
+	return &keySet{
+		cache:      cache,
+		config:     config,
+		source:     newJWKSSource(config),
+		ready:      make(chan struct{}),
+		loadSem:    make(chan struct{}, 1),
+		refreshSem: make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// close stops all background goroutines and blocks until they have ended or the given context is done
+func (k *keySet) close(ctx context.Context) error {
+	k.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		k.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// goBackground runs given function in a background goroutine which is tracked for close
+func (k *keySet) goBackground(fn func()) {
+	k.wg.Add(1)
+
+	go func() {
+		defer k.wg.Done()
+		fn()
+	}()
+}
+
+// isReady returns true if the JWKS has been loaded
+func (k *keySet) isReady() bool {
+	select {
+	case <-k.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitReady blocks until the JWKS has been loaded or the given context is done
+func (k *keySet) waitReady(ctx context.Context) error {
+	select {
+	case <-k.ready:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// ensureLoaded fetches the JWKS for the first time and starts the background refresh. It is safe to be
+// called concurrently, the JWKS is loaded exactly once (failed loads are retried on the next call).
+func (k *keySet) ensureLoaded(ctx context.Context) error {
+	if k.isReady() {
+		return nil
+	}
+
+	select {
+	case k.loadSem <- struct{}{}:
+	case <-k.ready:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+	defer func() { <-k.loadSem }()
+
+	// Another caller might have loaded the JWKS while we were waiting
+	if k.isReady() {
+		return nil
+	}
+
+	cacheEntry := k.loadCache()
+	if err := k.refresh(ctx); err != nil {
+		if !k.useCache(cacheEntry) {
+			return err
+		}
+
+		logger.Error("Error loading JWKS, using cached JWKS from '%s' instead: %s", k.config.JWKSCacheFile, err.Error())
+
+		close(k.ready)
+		k.goBackground(k.recoverFromCache)
+
+		return nil
+	}
+
+	close(k.ready)
+	k.goBackground(k.backgroundRefresh)
+
+	return nil
+}
+
+// recoverFromCache retries fetching the JWKS (with the backoff of eagerLoad) after the initial fetch failed and
+// the cached JWKS is used, the periodic refresh starts once a fetch succeeded
+func (k *keySet) recoverFromCache() {
+	backoff := k.capBackoff(keySetEagerLoadMinBackoff)
+
+	for {
+		k.setNextRefresh(time.Now().Add(backoff))
+
+		select {
+		case <-time.After(backoff):
+		case <-k.ctx.Done():
+			return
+		}
+
+		err := k.refresh(k.ctx)
+		if err == nil {
+			break
+		}
+
+		if k.ctx.Err() != nil {
+			return
+		}
+
+		logger.Error("Error refreshing JWKS (retrying in %s): %s", backoff, err.Error())
+		backoff = k.capBackoff(backoff * 2)
+	}
+
+	k.backgroundRefresh()
+}
+
+// eagerLoad loads the JWKS in the background and retries (with exponential backoff up to
+// JWKSRefreshRateLimit) until it succeeds
+func (k *keySet) eagerLoad() {
+	backoff := keySetEagerLoadMinBackoff
+
+	for {
+		err := k.ensureLoaded(k.ctx)
+		if err == nil || k.ctx.Err() != nil {
+			return
+		}
+
+		logger.Error("Error loading JWKS (retrying in %s): %s", backoff, err.Error())
+
+		select {
+		case <-time.After(backoff):
+		case <-k.ctx.Done():
+			return
+		}
+
+		backoff = k.capBackoff(backoff * 2)
+	}
+}
+
+// capBackoff returns given backoff capped at JWKSRefreshRateLimit
+func (k *keySet) capBackoff(backoff time.Duration) time.Duration {
+	if backoff > k.config.JWKSRefreshRateLimit {
+		return k.config.JWKSRefreshRateLimit
+	}
+
+	return backoff
+}
+
+// refresh fetches the JWKS from the source and replaces the current one (if it has changed)
+func (k *keySet) refresh(ctx context.Context) error {
+	select {
+	case k.refreshSem <- struct{}{}:
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+	defer func() { <-k.refreshSem }()
+
+	return k.update(ctx)
+}
+
+// update fetches the JWKS from the source and replaces the current one (must be called with refreshSem held)
+func (k *keySet) update(ctx context.Context) error {
+	k.mu.Lock()
+	k.lastAttempt = time.Now()
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, k.config.JWKSRefreshTimeout)
+	defer cancel()
+
+	raw, err := k.source.fetch(ctx)
+	if err != nil {
+		k.setError(err)
+		k.logStale()
+
+		return err
+	}
+
+	k.mu.RLock()
+	unchanged := k.jwks != nil && bytes.Equal(raw, k.raw)
+	k.mu.RUnlock()
+
+	if unchanged {
+		k.mu.Lock()
+		k.lastRefresh = time.Now()
+		k.mu.Unlock()
+
+		k.storeCache(raw)
+
+		return nil
+	}
+
+	jwks, err := keyfunc.NewJSON(raw)
+	if err != nil {
+		k.setError(err)
+
+		return errors.WithStack(err)
+	}
+
+	keys := describeKeys(jwks, raw)
+
+	k.mu.Lock()
+	k.jwks = jwks
+	k.raw = raw
+	k.keys = keys
+	k.lastRefresh = time.Now()
+	k.mu.Unlock()
+
+	k.changed()
+
+	k.storeCache(raw)
+
+	return nil
+}
+
+// loadCache reads the cache (if configured) and seeds the source with the cached ETag so that the next fetch is
+// a conditional request. It returns nil if there is no (readable) cache.
+func (k *keySet) loadCache() *jwksCacheEntry {
+	if k.cache == nil {
+		return nil
+	}
+
+	entry, err := k.cache.load()
+	if err != nil {
+		logger.Error("Error loading JWKS cache: %s", err.Error())
+
+		return nil
+	}
+
+	if entry == nil {
+		return nil
+	}
+
+	if httpSource, ok := k.source.(*httpSource); ok {
+		httpSource.setCurrent(entry.ETag, entry.JWKS)
+	}
+
+	return entry
+}
+
+// useCache replaces the current JWKS with the cached one, it returns true if the cached JWKS is within
+// JWKSCacheMaxStaleness and has been loaded
+func (k *keySet) useCache(entry *jwksCacheEntry) bool {
+	if entry == nil {
+		return false
+	}
+
+	if age := time.Since(entry.FetchedAt); age > k.config.JWKSCacheMaxStaleness {
+		logger.Error("Cached JWKS is too old to be used (age: %s, max staleness: %s)", age, k.config.JWKSCacheMaxStaleness)
+
+		return false
+	}
+
+	jwks, err := keyfunc.NewJSON(entry.JWKS)
+	if err != nil {
+		logger.Error("Error parsing cached JWKS: %s", err.Error())
+
+		return false
+	}
+
+	keys := describeKeys(jwks, entry.JWKS)
+
+	k.mu.Lock()
+	k.jwks = jwks
+	k.raw = entry.JWKS
+	k.keys = keys
+	k.lastRefresh = entry.FetchedAt
+	k.mu.Unlock()
+
+	k.changed()
+
+	logger.Info("Serving JWKS from cache (fetched at: %s)", entry.FetchedAt.Format(time.RFC3339))
+
+	return true
+}
+
+// storeCache writes the given (successfully fetched) JWKS to the cache (if configured)
+func (k *keySet) storeCache(raw []byte) {
+	if k.cache == nil || k.source.isLocal() {
+		return
+	}
+
+	entry := &jwksCacheEntry{
+		FetchedAt: time.Now(),
+		JWKS:      raw,
+	}
+
+	if httpSource, ok := k.source.(*httpSource); ok {
+		entry.ETag, _ = httpSource.current()
+	}
+
+	if err := k.cache.store(entry); err != nil {
+		logger.Error("Error storing JWKS cache: %s", err.Error())
+	}
+}
+
+// logStale logs if the current JWKS is older than JWKSCacheMaxStaleness (and still used because refreshing
+// it failed)
+func (k *keySet) logStale() {
+	if k.cache == nil {
+		return
+	}
+
+	k.mu.RLock()
+	loaded := k.jwks != nil
+	age := time.Since(k.lastRefresh)
+	k.mu.RUnlock()
+
+	if loaded && age > k.config.JWKSCacheMaxStaleness {
+		logger.Error("Serving stale JWKS because refreshing it failed (age: %s, max staleness: %s)", age, k.config.JWKSCacheMaxStaleness)
+	}
+}
+
+// changed calls the onChange hook (if set)
+func (k *keySet) changed() {
+	if k.onChange != nil {
+		k.onChange()
+	}
+}
+
+// setError records the error of a failed refresh
+func (k *keySet) setError(err error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.lastErr = err
+	k.lastErrAt = time.Now()
+}
+
+// setNextRefresh records the time of the next scheduled refresh
+func (k *keySet) setNextRefresh(next time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.nextRefresh = next
+}
+
+// status returns the current status of the key set
+func (k *keySet) status() *entities.KeySetStatus {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	status := &entities.KeySetStatus{
+		Ready:       k.isReady(),
+		Source:      k.source.kind(),
+		Keys:        append([]entities.KeyStatus{}, k.keys...),
+		LastRefresh: k.lastRefresh,
+		NextRefresh: k.nextRefresh,
+		LastErrorAt: k.lastErrAt,
+	}
+
+	if k.lastErr != nil {
+		status.LastError = k.lastErr.Error()
+	}
+
+	if k.ctx.Err() != nil {
+		status.NextRefresh = time.Time{}
+	}
+
+	return status
+}
+
+// describeKeys returns the status of all keys in the given JWKS (raw is needed because keyfunc does not expose
+// the key type and algorithm)
+func describeKeys(jwks *keyfunc.JWKS, raw []byte) []entities.KeyStatus {
+	var rawKeys struct {
+		Keys []struct {
+			KeyID     string `json:"kid"`
+			KeyType   string `json:"kty"`
+			Algorithm string `json:"alg"`
+		} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(raw, &rawKeys); err != nil {
+		return nil
+	}
+
+	publicKeys := jwks.ReadOnlyKeys()
+	keys := make([]entities.KeyStatus, 0, len(rawKeys.Keys))
+
+	for _, rawKey := range rawKeys.Keys {
+		publicKey, ok := publicKeys[rawKey.KeyID]
+		if !ok {
+			// Unsupported key type (ignored by keyfunc as well)
+			continue
+		}
+
+		key := entities.KeyStatus{
+			KeyID:     rawKey.KeyID,
+			KeyType:   rawKey.KeyType,
+			Algorithm: rawKey.Algorithm,
+		}
+
+		if thumbprint, err := thumbprint(publicKey); err == nil {
+			key.Thumbprint = thumbprint
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// backgroundRefresh refreshes the JWKS periodically (see JWKSRefreshInterval and JWKSFilePollInterval)
+func (k *keySet) backgroundRefresh() {
+	if _, ok := k.source.(*staticSource); ok {
+		return
+	}
+
+	interval := k.config.JWKSRefreshInterval
+	if _, ok := k.source.(*fsSource); ok && k.config.JWKSFilePollInterval > 0 {
+		interval = k.config.JWKSFilePollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	k.setNextRefresh(time.Now().Add(interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			k.setNextRefresh(time.Now().Add(interval))
+
+			if err := k.refresh(k.ctx); err != nil && k.ctx.Err() == nil {
+				logger.Error("Error refreshing JWKS: %s", err.Error())
+			}
+
+		case <-k.ctx.Done():
+			return
+		}
+	}
+}
+
+// refreshUnknownKID refreshes the JWKS because a token with an unknown key ID was seen. It returns true if the
+// JWKS has been refreshed (respecting JWKSRefreshRateLimit). Concurrent callers share one refresh: callers waiting
+// for an in-flight refresh do not fetch again but use its result.
+func (k *keySet) refreshUnknownKID(ctx context.Context) (bool, error) {
+	k.mu.RLock()
+	observed := k.lastAttempt
+	k.mu.RUnlock()
+
+	if time.Since(observed) < k.config.JWKSRefreshRateLimit {
+		return false, nil
+	}
+
+	select {
+	case k.refreshSem <- struct{}{}:
+	case <-ctx.Done():
+		return false, errors.WithStack(ctx.Err())
+	}
+	defer func() { <-k.refreshSem }()
+
+	// Check again, another caller might have refreshed while waiting for the semaphore
+	k.mu.RLock()
+	lastAttempt := k.lastAttempt
+	k.mu.RUnlock()
+
+	if lastAttempt.After(observed) {
+		return true, nil
+	}
+
+	if err := k.update(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// keyfunc returns a jwt.Keyfunc which looks up the signing key and refreshes the JWKS (bound to given context)
+// if the key ID is unknown
+func (k *keySet) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		k.mu.RLock()
+		jwks := k.jwks
+		k.mu.RUnlock()
+
+		key, err := jwks.Keyfunc(token)
+		if !errors.Is(err, keyfunc.ErrKIDNotFound) {
+			return key, err
+		}
+
+		refreshed, refreshErr := k.refreshUnknownKID(ctx)
+		if refreshErr != nil {
+			return nil, fmt.Errorf("%w (refresh failed: %s)", refreshErr, err.Error())
+		}
+
+		if !refreshed {
+			return nil, err
+		}
+
+		k.mu.RLock()
+		jwks = k.jwks
+		k.mu.RUnlock()
+
+		return jwks.Keyfunc(token)
+	}
+}
@@ -2,32 +2,52 @@ package session
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"time"
 
 	"github.com/MicahParks/keyfunc"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 
-	"github.com/corbado/corbado-go/v2/pkg/logger"
 	"github.com/corbado/corbado-go/v2/pkg/validationerror"
 
 	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/internal/lifecycle"
+	"github.com/corbado/corbado-go/v2/pkg/denylist"
 	"github.com/corbado/corbado-go/v2/pkg/entities"
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
+)
+
+const defaultAlgorithm = "RS256"
+
+var (
+	errAlgorithmNotAllowed = errors.New("signing algorithm not allowed")
+	errUnknownKey          = errors.New("unknown signing key")
 )
 
 type Session interface {
 	ValidateToken(sessionToken string) (*entities.User, error)
+	ValidateTokenWithContext(ctx context.Context, sessionToken string) (*entities.User, error)
+	ValidateTokenWithClaims(ctx context.Context, sessionToken string, claims entities.CustomClaims, validators ...entities.ClaimsValidator) (*entities.User, error)
+	ValidateTokenStrict(ctx context.Context, sessionToken string) (*entities.User, error)
+	Ready() bool
+	WaitReady(ctx context.Context) error
+	KeySetStatus() *entities.KeySetStatus
+	TokenCacheStats() entities.TokenCacheStats
+	Close(ctx context.Context) error
 }
 
 type Impl struct {
 	Client *api.ClientWithResponses
 	Config *Config
-	Jwks   *keyfunc.JWKS
+
+	keys    *keySet
+	tokens  *tokenCache // nilable
+	strict  *strictCache
+	tracker *lifecycle.Tracker
+	tracer  trace.Tracer
 }
 
 var _ Session = &Impl{}
@@ -42,126 +62,453 @@ func New(client *api.ClientWithResponses, config *Config) (*Impl, error) {
 		return nil, err
 	}
 
-	return &Impl{
-		Client: client,
-		Config: config,
-	}, nil
+	impl := &Impl{
+		Client:  client,
+		Config:  config,
+		keys:    newKeySet(config),
+		strict:  newStrictCache(strictCacheCapacity),
+		tracker: lifecycle.NewTracker(),
+		tracer:  tracing.NewTracer(config.TracerProvider),
+	}
+
+	if config.TokenCacheSize > 0 {
+		impl.tokens = newTokenCache(config.TokenCacheSize)
+		impl.keys.onChange = impl.tokens.purge
+	}
+
+	switch {
+	case config.JWKSFailFast, impl.keys.source.isLocal():
+		if err := impl.keys.ensureLoaded(context.Background()); err != nil {
+			return nil, errors.WithMessage(err, "Loading JWKS failed")
+		}
+
+	case config.JWKSEagerLoad:
+		impl.keys.goBackground(impl.keys.eagerLoad)
+	}
+
+	return impl, nil
 }
 
-func newJWKS(config *Config) (*keyfunc.JWKS, error) {
-	options := keyfunc.Options{
-		RequestFactory: func(_ context.Context, urlAddress string) (*http.Request, error) {
-			address, err := url.Parse(urlAddress)
-			if err != nil {
-				return nil, errors.WithStack(err)
-			}
+// Ready returns true if the JWKS has been loaded and session tokens can be validated without fetching it first
+func (i *Impl) Ready() bool {
+	return i.keys.isReady()
+}
 
-			req := &http.Request{
-				Method: http.MethodGet,
-				URL:    address,
-				Header: map[string][]string{
-					"X-Corbado-ProjectID": {config.ProjectID},
-				},
-			}
+// WaitReady blocks until the JWKS has been loaded or the given context is done, it can be used for readiness
+// checks (together with JWKSEagerLoad)
+func (i *Impl) WaitReady(ctx context.Context) error {
+	if err := assert.NotNil(ctx); err != nil {
+		return err
+	}
 
-			return req, nil
-		},
-		ResponseExtractor: func(_ context.Context, resp *http.Response) (json.RawMessage, error) {
-			rspBody, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
+	return i.keys.waitReady(ctx)
+}
+
+// KeySetStatus returns the status of the JWKS used to validate session tokens (loaded keys, last refresh,
+// last error and next scheduled refresh)
+func (i *Impl) KeySetStatus() *entities.KeySetStatus {
+	return i.keys.status()
+}
 
-			return rspBody, nil
-		},
-		RefreshErrorHandler: func(err error) {
-			logger.Error("Error refreshing JWKS: %s", err.Error())
-		},
-		RefreshInterval:   config.JWKSRefreshInterval,
-		RefreshRateLimit:  config.JWKSRefreshRateLimit,
-		RefreshTimeout:    config.JWKSRefreshTimeout,
-		RefreshUnknownKID: true,
+// TokenCacheStats returns the metrics of the validated session token cache (see TokenCacheSize)
+func (i *Impl) TokenCacheStats() entities.TokenCacheStats {
+	if i.tokens == nil {
+		return entities.TokenCacheStats{}
 	}
 
-	return keyfunc.Get(config.JwksURI, options)
+	return i.tokens.stats()
 }
 
+// Close stops the background JWKS refresh and waits for in-flight validations to finish (or the given
+// context to be done), all validations afterward fail with lifecycle.ErrClosed
+func (i *Impl) Close(ctx context.Context) error {
+	if err := assert.NotNil(ctx); err != nil {
+		return err
+	}
+
+	// Stop the background refresh even if draining fails to not leak goroutines
+	drainErr := i.tracker.Close(ctx)
+	if err := i.keys.close(ctx); err != nil {
+		return err
+	}
+
+	return drainErr
+}
+
+// ValidateToken validates the given session token (short-term session) and returns the user
 func (i *Impl) ValidateToken(sessionToken string) (*entities.User, error) {
+	return i.ValidateTokenWithContext(context.Background(), sessionToken)
+}
+
+// ValidateTokenWithContext validates the given session token (short-term session) and returns the user. JWKS
+// fetches (initial and on unknown key IDs) are bound to the given context.
+func (i *Impl) ValidateTokenWithContext(ctx context.Context, sessionToken string) (*entities.User, error) {
+	return i.ValidateTokenWithClaims(ctx, sessionToken, &entities.Claims{})
+}
+
+// ValidateTokenWithClaims validates the given session token (short-term session) like ValidateTokenWithContext
+// but decodes the claims into given (custom) claims. Given validators are called after all other checks have
+// passed and can be used to enforce business rules on the claims. The token cache (see TokenCacheSize) is only
+// used for the default claims without validators.
+func (i *Impl) ValidateTokenWithClaims(
+	ctx context.Context,
+	sessionToken string,
+	claims entities.CustomClaims,
+	validators ...entities.ClaimsValidator,
+) (*entities.User, error) {
+	if err := assert.NotNil(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, span := i.startSpan(ctx, "ValidateToken")
+	defer span.End()
+
+	user, err := i.validateTokenWithClaims(ctx, sessionToken, claims, validators...)
+	i.endSpan(span, err)
+
+	return user, err
+}
+
+func (i *Impl) validateTokenWithClaims(
+	ctx context.Context,
+	sessionToken string,
+	claims entities.CustomClaims,
+	validators ...entities.ClaimsValidator,
+) (*entities.User, error) {
+	if err := assert.NotNil(ctx, claims); err != nil {
+		return nil, err
+	}
+
 	if err := assert.StringNotEmpty(sessionToken); err != nil {
 		return nil, err
 	}
 
-	if i.Jwks == nil {
-		jwks, err := newJWKS(i.Config)
-		if err != nil {
-			return nil, err
+	if err := i.tracker.Acquire(); err != nil {
+		return nil, err
+	}
+	defer i.tracker.Release()
+
+	defaultClaims, cacheable := claims.(*entities.Claims)
+	cacheable = cacheable && i.tokens != nil && len(validators) == 0
+
+	if cacheable {
+		if cachedClaims, ok := i.tokens.get(sessionToken, i.now()); ok {
+			if err := i.validateNotRevoked(ctx, cachedClaims, sessionToken); err != nil {
+				return nil, err
+			}
+
+			*defaultClaims = *cachedClaims
+
+			return i.newUser(defaultClaims, sessionToken), nil
+		}
+	}
+
+	if err := i.keys.ensureLoaded(ctx); err != nil {
+		if code, ok := contextErrorCode(err); ok {
+			return nil, i.wrapValidationError(err, sessionToken, code)
 		}
 
-		i.Jwks = jwks
+		return nil, err
+	}
+
+	// Read before parsing so that a JWKS change during validation keeps the token out of the cache
+	var generation uint64
+	if cacheable {
+		generation = i.tokens.currentGeneration()
 	}
 
-	token, err := jwt.ParseWithClaims(sessionToken, &entities.Claims{}, i.Jwks.Keyfunc)
+	// Time based claims are validated by validateTimes to support leeway and a custom clock
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+
+	token, err := parser.ParseWithClaims(sessionToken, claims, i.keyfunc(ctx))
 	if err != nil {
 		code := validationerror.CodeJWTGeneral
 		libraryValidationErr := &jwt.ValidationError{}
 
-		if errors.As(err, &libraryValidationErr) {
+		if contextCode, ok := contextErrorCode(err); ok {
+			code = contextCode
+		} else if errors.Is(err, errAlgorithmNotAllowed) {
+			code = validationerror.CodeJWTAlgorithmNotAllowed
+		} else if errors.Is(err, errUnknownKey) {
+			code = validationerror.CodeJWTUnknownKey
+		} else if errors.As(err, &libraryValidationErr) {
 			switch {
 			case libraryValidationErr.Errors&jwt.ValidationErrorMalformed != 0:
 				code = validationerror.CodeJWTInvalidData
 
 			case libraryValidationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0:
 				code = validationerror.CodeJWTInvalidSignature
+			}
+		}
+
+		return nil, i.wrapValidationError(err, sessionToken, code)
+	}
 
-			case libraryValidationErr.Errors&jwt.ValidationErrorNotValidYet != 0:
-				code = validationerror.CodeJWTBefore
+	corbadoClaims := token.Claims.(entities.CustomClaims).CorbadoClaims()
+	if err := i.validateTimes(corbadoClaims, sessionToken); err != nil {
+		return nil, err
+	}
 
-			case libraryValidationErr.Errors&jwt.ValidationErrorExpired != 0:
-				code = validationerror.CodeJWTExpired
+	if err := i.validateIssuer(corbadoClaims.Issuer, sessionToken); err != nil {
+		return nil, err
+	}
+
+	if err := i.validateNotRevoked(ctx, corbadoClaims, sessionToken); err != nil {
+		return nil, err
+	}
+
+	for _, validator := range validators {
+		if err := validator(claims); err != nil {
+			return nil, i.wrapValidationError(err, sessionToken, validationerror.CodeJWTClaimsInvalid)
+		}
+	}
+
+	if cacheable {
+		if validUntil, ok := i.cacheValidUntil(corbadoClaims); ok {
+			i.tokens.add(sessionToken, corbadoClaims, validUntil, generation)
+		}
+	}
+
+	return i.newUser(corbadoClaims, sessionToken), nil
+}
+
+// newUser returns new user from given (validated) claims
+func (i *Impl) newUser(claims *entities.Claims, sessionToken string) *entities.User {
+	user := entities.NewUser(claims, sessionToken)
+	user.ProjectID = i.Config.ProjectID
+
+	return user
+}
+
+// validateNotRevoked consults the denylist (if configured)
+func (i *Impl) validateNotRevoked(ctx context.Context, claims *entities.Claims, sessionToken string) error {
+	if i.Config.Denylist == nil {
+		return nil
+	}
+
+	token := &denylist.Token{
+		ID:     claims.ID,
+		UserID: claims.Subject,
+	}
+
+	if claims.IssuedAt != nil {
+		token.IssuedAt = claims.IssuedAt.Time
+	}
+
+	if claims.ExpiresAt != nil {
+		token.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	revoked, err := i.Config.Denylist.IsRevoked(ctx, token)
+	if err != nil {
+		if code, ok := contextErrorCode(err); ok {
+			return i.wrapValidationError(err, sessionToken, code)
+		}
+
+		return errors.WithMessage(err, "Denylist lookup failed")
+	}
+
+	if revoked {
+		return i.newValidationError("Token has been revoked", sessionToken, validationerror.CodeJWTRevoked)
+	}
+
+	return nil
+}
+
+// cacheValidUntil returns the time until which a validated token with given claims may be served from the
+// token cache (the same time validateTimes would reject it), tokens without exp are not cached
+func (i *Impl) cacheValidUntil(claims *entities.Claims) (time.Time, bool) {
+	if claims.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+
+	validUntil := claims.ExpiresAt.Add(i.Config.Leeway)
+
+	if i.Config.MaxTokenAge > 0 && claims.IssuedAt != nil {
+		maxAgeUntil := claims.IssuedAt.Add(i.Config.MaxTokenAge + i.Config.Leeway)
+		if maxAgeUntil.Before(validUntil) {
+			validUntil = maxAgeUntil
+		}
+	}
+
+	return validUntil, true
+}
+
+// keyfunc returns a jwt.Keyfunc which enforces the allowed algorithms and pinned keys before returning the
+// key from the JWKS
+func (i *Impl) keyfunc(ctx context.Context) jwt.Keyfunc {
+	jwksKeyfunc := i.keys.keyfunc(ctx)
+
+	return func(token *jwt.Token) (any, error) {
+		alg := token.Method.Alg()
+		if !i.algorithmAllowed(alg) {
+			return nil, errors.Wrapf(errAlgorithmNotAllowed, "algorithm '%s'", alg)
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		if len(i.Config.PinnedKeyIDs) > 0 && !contains(i.Config.PinnedKeyIDs, kid) {
+			return nil, errors.Wrapf(errUnknownKey, "key ID '%s' is not pinned", kid)
+		}
+
+		key, err := jwksKeyfunc(token)
+		if err != nil {
+			if errors.Is(err, keyfunc.ErrKIDNotFound) || errors.Is(err, keyfunc.ErrKID) {
+				return nil, errors.Wrapf(errUnknownKey, "key ID '%s' (%s)", kid, err.Error())
 			}
+
+			return nil, err
 		}
 
-		return nil, newValidationError(err.Error(), sessionToken, code)
+		if len(i.Config.PinnedKeyThumbprints) > 0 {
+			keyThumbprint, err := thumbprint(key)
+			if err != nil {
+				return nil, err
+			}
+
+			if !contains(i.Config.PinnedKeyThumbprints, keyThumbprint) {
+				return nil, errors.Wrapf(errUnknownKey, "thumbprint '%s' of key ID '%s' is not pinned", keyThumbprint, kid)
+			}
+		}
+
+		return key, nil
 	}
+}
 
-	claims := token.Claims.(*entities.Claims)
-	if err := i.validateIssuer(claims.Issuer, sessionToken); err != nil {
-		return nil, err
+// algorithmAllowed returns true if given algorithm is allowed (RS256 only by default)
+func (i *Impl) algorithmAllowed(alg string) bool {
+	if len(i.Config.Algorithms) == 0 {
+		return alg == defaultAlgorithm
+	}
+
+	return contains(i.Config.Algorithms, alg)
+}
+
+// validateTimes validates the time based claims (exp, nbf and iat) with the configured leeway and clock
+func (i *Impl) validateTimes(claims *entities.Claims, sessionToken string) error {
+	now := i.now()
+	leeway := i.Config.Leeway
+
+	if claims.ExpiresAt != nil && now.After(claims.ExpiresAt.Add(leeway)) {
+		return i.newValidationError(
+			fmt.Sprintf("Token is expired (exp: %s)", claims.ExpiresAt.UTC().Format(time.RFC3339)),
+			sessionToken,
+			validationerror.CodeJWTExpired,
+		)
+	}
+
+	if claims.NotBefore != nil && now.Add(leeway).Before(claims.NotBefore.Time) {
+		return i.newValidationError(
+			fmt.Sprintf("Token is not valid yet (nbf: %s)", claims.NotBefore.UTC().Format(time.RFC3339)),
+			sessionToken,
+			validationerror.CodeJWTBefore,
+		)
+	}
+
+	if claims.IssuedAt != nil && now.Add(leeway).Before(claims.IssuedAt.Time) {
+		return i.newValidationError(
+			fmt.Sprintf("Token used before issued (iat: %s)", claims.IssuedAt.UTC().Format(time.RFC3339)),
+			sessionToken,
+			validationerror.CodeJWTBefore,
+		)
+	}
+
+	if i.Config.MaxTokenAge > 0 {
+		if claims.IssuedAt == nil {
+			return i.newValidationError("Token has no issued at (iat) but a maximum token age is configured", sessionToken, validationerror.CodeJWTTooOld)
+		}
+
+		if now.Sub(claims.IssuedAt.Time) > i.Config.MaxTokenAge+leeway {
+			return i.newValidationError(
+				fmt.Sprintf("Token is too old (iat: %s, max age: %s)", claims.IssuedAt.UTC().Format(time.RFC3339), i.Config.MaxTokenAge),
+				sessionToken,
+				validationerror.CodeJWTTooOld,
+			)
+		}
+	}
+
+	return nil
+}
+
+// now returns the current time of the configured clock
+func (i *Impl) now() time.Time {
+	if i.Config.Clock != nil {
+		return i.Config.Clock()
 	}
 
-	return &entities.User{
-		UserID:   claims.Subject,
-		FullName: claims.Name,
-	}, nil
+	return time.Now()
 }
 
 func (i *Impl) validateIssuer(jwtIssuer string, sessionToken string) error {
 	if jwtIssuer == "" {
-		return newValidationError("Issuer is empty", sessionToken, validationerror.CodeJWTIssuerEmpty)
+		return i.newValidationError("Issuer is empty", sessionToken, validationerror.CodeJWTIssuerEmpty)
 	}
 
-	// Compare to old Frontend API (without .cloud.) to make our Frontend API host name change downwards compatible
-	if jwtIssuer == fmt.Sprintf("https://%s.frontendapi.corbado.io", i.Config.ProjectID) {
+	if i.Config.AcceptsIssuer(jwtIssuer) {
 		return nil
 	}
 
-	// Compare to new Frontend API (with .cloud.)
-	if jwtIssuer == fmt.Sprintf("https://%s.frontendapi.cloud.corbado.io", i.Config.ProjectID) {
-		return nil
+	return i.newValidationError(
+		fmt.Sprintf("Issuer mismatch (configured trough FrontendAPI: '%s', JWT issuer: '%s')", i.Config.JWTIssuer, jwtIssuer),
+		sessionToken,
+		validationerror.CodeJWTIssuerMismatch,
+	)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
 
-	// Compare to configured issuer (from FrontendAPI), needed if you set a CNAME for example
-	if jwtIssuer != i.Config.JWTIssuer {
-		return newValidationError(
-			fmt.Sprintf("Issuer mismatch (configured trough FrontendAPI: '%s', JWT issuer: '%s')", i.Config.JWTIssuer, jwtIssuer),
-			sessionToken,
-			validationerror.CodeJWTIssuerMismatch,
-		)
+	return false
+}
+
+// contextErrorCode returns the validation error code if given error was caused by an expired or cancelled context
+func contextErrorCode(err error) (validationerror.Code, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return validationerror.CodeTimeout, true
+
+	case errors.Is(err, context.Canceled):
+		return validationerror.CodeCanceled, true
 	}
 
-	return nil
+	return 0, false
 }
 
-func newValidationError(message string, jwt string, code validationerror.Code) error {
-	return validationerror.New(fmt.Sprintf("JWT validation failed: '%s' (JWT: '%s')", message, jwt), code)
+// newValidationError returns a validation error with diagnostic details of given session token (see
+// wrapValidationError)
+func (i *Impl) newValidationError(message string, sessionToken string, code validationerror.Code) error {
+	return i.newValidationErrorWithCause(nil, message, sessionToken, code)
+}
+
+// wrapValidationError returns a validation error for given underlying error (e.g. of the JWT library)
+func (i *Impl) wrapValidationError(err error, sessionToken string, code validationerror.Code) error {
+	return i.newValidationErrorWithCause(err, err.Error(), sessionToken, code)
+}
+
+// newValidationErrorWithCause returns a validation error which contains the issuer, key ID and expiry (taken
+// from the unverified token if it can be decoded) and a fingerprint of the session token, the token itself is
+// only contained if IncludeTokenInErrors is set
+func (i *Impl) newValidationErrorWithCause(err error, message string, sessionToken string, code validationerror.Code) error {
+	validationErr := validationerror.Wrap(err, fmt.Sprintf("JWT validation failed: '%s'", message), code)
+	validationErr.Reason = message
+	validationErr.TokenFingerprint = validationerror.Fingerprint(sessionToken)
+
+	if i.Config.IncludeTokenInErrors {
+		validationErr.Token = sessionToken
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	if token, _, parseErr := jwt.NewParser().ParseUnverified(sessionToken, claims); parseErr == nil {
+		validationErr.KeyID, _ = token.Header["kid"].(string)
+		validationErr.Issuer = claims.Issuer
+
+		if claims.ExpiresAt != nil {
+			validationErr.ExpiresAt = claims.ExpiresAt.Time
+		}
+	}
+
+	return validationErr
 }
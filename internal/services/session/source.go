@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// jwksSource provides the raw JWKS (JSON)
+type jwksSource interface {
+	fetch(ctx context.Context) ([]byte, error)
+
+	// isLocal returns true if the source does not need network access (and can be loaded right away)
+	isLocal() bool
+
+	// kind returns the name of the source ("http", "file" or "static")
+	kind() string
+}
+
+// newJWKSSource returns the JWKS source selected by the config: static bytes, a file (optionally in an fs.FS)
+// or the JWKS URI (default)
+func newJWKSSource(config *Config) jwksSource {
+	switch {
+	case len(config.JWKS) > 0:
+		return &staticSource{data: config.JWKS}
+
+	case config.JWKSFile != "" && config.JWKSFS != nil:
+		return &fsSource{fsys: config.JWKSFS, name: config.JWKSFile}
+
+	case config.JWKSFile != "":
+		return &fsSource{fsys: os.DirFS(filepath.Dir(config.JWKSFile)), name: filepath.Base(config.JWKSFile)}
+
+	default:
+		return &httpSource{config: config}
+	}
+}
+
+// httpSource fetches the JWKS from the JWKS URI, conditional requests (ETag) are used if possible
+type httpSource struct {
+	config *Config
+
+	mu   sync.Mutex
+	etag string
+	body []byte
+}
+
+func (h *httpSource) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.config.JwksURI, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req.Header.Set("X-Corbado-ProjectID", h.config.ProjectID)
+
+	etag, body := h.current()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	httpClient := h.config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	rsp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotModified && body != nil {
+		return body, nil
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Unexpected HTTP status code %d while fetching JWKS", rsp.StatusCode)
+	}
+
+	rspBody, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	h.setCurrent(rsp.Header.Get("ETag"), rspBody)
+
+	return rspBody, nil
+}
+
+// current returns the ETag and body of the last response
+func (h *httpSource) current() (string, []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.etag, h.body
+}
+
+// setCurrent sets the ETag and body of the last response (or the cache)
+func (h *httpSource) setCurrent(etag string, body []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.etag = etag
+	h.body = body
+}
+
+func (h *httpSource) isLocal() bool {
+	return false
+}
+
+func (h *httpSource) kind() string {
+	return "http"
+}
+
+// staticSource provides a JWKS given as bytes
+type staticSource struct {
+	data []byte
+}
+
+func (s *staticSource) fetch(_ context.Context) ([]byte, error) {
+	return s.data, nil
+}
+
+func (s *staticSource) isLocal() bool {
+	return true
+}
+
+func (s *staticSource) kind() string {
+	return "static"
+}
+
+// fsSource reads the JWKS from a file, it is re-read on every refresh so that changes are picked up
+type fsSource struct {
+	fsys fs.FS
+	name string
+}
+
+func (f *fsSource) fetch(_ context.Context) ([]byte, error) {
+	data, err := fs.ReadFile(f.fsys, f.name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
+
+func (f *fsSource) isLocal() bool {
+	return true
+}
+
+func (f *fsSource) kind() string {
+	return "file"
+}
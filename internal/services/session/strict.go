@@ -0,0 +1,218 @@
+package session
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/servererror"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+// strictCacheCapacity is the maximum number of cached online check results, the oldest result is evicted if the
+// cache is full
+const strictCacheCapacity = 10000
+
+// strictCache is a bounded cache of the results of online checks (see ValidateTokenStrict) for StrictCacheTTL.
+// Results are ordered by insertion, because all of them are cached for the same TTL the oldest result expires
+// first so that expired results can be removed from the back without scanning the whole cache.
+type strictCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// onlineStatus is the result of an online check, the validation error is built from it for every session token
+// so that cached results do not contain details of the session token they have been fetched for
+type onlineStatus struct {
+	active  bool
+	message string
+	code    validationerror.Code
+}
+
+type strictCacheEntry struct {
+	key       string
+	status    onlineStatus
+	expiresAt time.Time
+}
+
+func newStrictCache(capacity int) *strictCache {
+	return &strictCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the cached status for given key, ok is false if there is none (or it has expired)
+func (c *strictCache) get(key string, now time.Time) (status onlineStatus, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return onlineStatus{}, false
+	}
+
+	entry := element.Value.(*strictCacheEntry)
+	if now.After(entry.expiresAt) {
+		return onlineStatus{}, false
+	}
+
+	return entry.status, true
+}
+
+// add caches the status for given key until expiresAt, expired statuses are removed and the oldest status is
+// evicted if the cache is full
+func (c *strictCache) add(key string, status onlineStatus, now time.Time, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	for oldest := c.order.Back(); oldest != nil; oldest = c.order.Back() {
+		if !now.After(oldest.Value.(*strictCacheEntry).expiresAt) && c.order.Len() < c.capacity {
+			break
+		}
+
+		c.remove(oldest)
+	}
+
+	c.entries[key] = c.order.PushFront(&strictCacheEntry{
+		key:       key,
+		status:    status,
+		expiresAt: expiresAt,
+	})
+}
+
+// remove removes given element (must be called with mu held)
+func (c *strictCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*strictCacheEntry).key)
+}
+
+// ValidateTokenStrict validates the given session token like ValidateTokenWithContext and additionally checks
+// through the Backend API that the user and the long session are still active. Session tokens without a session
+// ID (sid) are rejected because the long session cannot be checked. Results of the online check are cached for
+// StrictCacheTTL.
+func (i *Impl) ValidateTokenStrict(ctx context.Context, sessionToken string) (*entities.User, error) {
+	if err := assert.NotNil(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, span := i.startSpan(ctx, "ValidateTokenStrict")
+	defer span.End()
+
+	user, err := i.validateTokenStrict(ctx, sessionToken)
+	i.endSpan(span, err)
+
+	return user, err
+}
+
+func (i *Impl) validateTokenStrict(ctx context.Context, sessionToken string) (*entities.User, error) {
+	user, err := i.ValidateTokenWithContext(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.verifyOnline(ctx, user.UserID, user.Claims.SessionID, sessionToken); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// verifyOnline checks the status of the user and long session through the Backend API (cached)
+func (i *Impl) verifyOnline(ctx context.Context, userID string, longSessionID string, sessionToken string) error {
+	if err := assert.StringNotEmpty(userID); err != nil {
+		return i.newValidationError("Subject (sub) is empty", sessionToken, validationerror.CodeJWTInvalidData)
+	}
+
+	if err := assert.StringNotEmpty(longSessionID); err != nil {
+		return i.newValidationError("Session ID (sid) is empty", sessionToken, validationerror.CodeJWTInvalidData)
+	}
+
+	cacheKey := userID + "\x00" + longSessionID
+
+	status, ok := i.strict.get(cacheKey, i.now())
+	if !ok {
+		var err error
+
+		status, err = i.fetchOnlineStatus(ctx, userID, longSessionID)
+		if err != nil {
+			if code, ok := contextErrorCode(err); ok {
+				return i.wrapValidationError(err, sessionToken, code)
+			}
+
+			return err
+		}
+
+		if i.Config.StrictCacheTTL > 0 {
+			now := i.now()
+			i.strict.add(cacheKey, status, now, now.Add(i.Config.StrictCacheTTL))
+		}
+	}
+
+	if !status.active {
+		return i.newValidationError(status.message, sessionToken, status.code)
+	}
+
+	return nil
+}
+
+// fetchOnlineStatus returns the status of the user and long session, err is only returned if the Backend API
+// could not be asked
+func (i *Impl) fetchOnlineStatus(ctx context.Context, userID string, longSessionID string) (onlineStatus, error) {
+	userRsp, err := i.Client.UserGetWithResponse(tracing.WithOperation(ctx, "UserGet"), userID)
+	if err != nil {
+		return onlineStatus{}, errors.WithStack(err)
+	}
+
+	if userRsp.JSONDefault != nil {
+		return onlineStatus{}, servererror.NewFromResponse(userRsp.JSONDefault, userRsp.HTTPResponse)
+	}
+
+	if userRsp.JSON200 == nil {
+		return onlineStatus{}, errors.Errorf("Unexpected HTTP status code %d while getting user", userRsp.StatusCode())
+	}
+
+	if userRsp.JSON200.Status != api.UserStatusActive {
+		return onlineStatus{
+			message: fmt.Sprintf("User is not active (status: %s)", userRsp.JSON200.Status),
+			code:    validationerror.CodeUserNotActive,
+		}, nil
+	}
+
+	longSessionRsp, err := i.Client.UserLongSessionGetWithResponse(tracing.WithOperation(ctx, "UserLongSessionGet"), userID, longSessionID)
+	if err != nil {
+		return onlineStatus{}, errors.WithStack(err)
+	}
+
+	if longSessionRsp.JSONDefault != nil {
+		return onlineStatus{}, servererror.NewFromResponse(longSessionRsp.JSONDefault, longSessionRsp.HTTPResponse)
+	}
+
+	if longSessionRsp.JSON200 == nil {
+		return onlineStatus{}, errors.Errorf("Unexpected HTTP status code %d while getting long session", longSessionRsp.StatusCode())
+	}
+
+	if longSessionRsp.JSON200.Status != api.Active {
+		return onlineStatus{
+			message: fmt.Sprintf("Long session is not active (status: %s)", longSessionRsp.JSON200.Status),
+			code:    validationerror.CodeLongSessionNotActive,
+		}, nil
+	}
+
+	return onlineStatus{active: true}, nil
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+)
+
+// tokenCache is a bounded LRU cache of successfully validated session tokens. Tokens are keyed by their SHA-256
+// hash so that the cache does not hold the raw tokens.
+type tokenCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[[sha256.Size]byte]*list.Element
+	lru      *list.List
+
+	// generation is incremented on every purge, tokens validated with the JWKS of an older generation are not
+	// added anymore
+	generation uint64
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type tokenCacheEntry struct {
+	key        [sha256.Size]byte
+	claims     entities.Claims
+	validUntil time.Time
+}
+
+func newTokenCache(capacity int) *tokenCache {
+	return &tokenCache{
+		capacity: capacity,
+		entries:  make(map[[sha256.Size]byte]*list.Element, capacity),
+		lru:      list.New(),
+	}
+}
+
+// get returns a copy of the claims of given token if it has been validated before and is still valid at given
+// time
+func (c *tokenCache) get(sessionToken string, now time.Time) (*entities.Claims, bool) {
+	key := sha256.Sum256([]byte(sessionToken))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+
+		return nil, false
+	}
+
+	entry := element.Value.(*tokenCacheEntry)
+	if now.After(entry.validUntil) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		c.misses++
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	c.hits++
+
+	claims := entry.claims
+
+	return &claims, true
+}
+
+// currentGeneration returns the generation to be passed to add, it must be read before the token is validated
+func (c *tokenCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// add caches (a copy of) the claims of given token until validUntil, the least recently used token is evicted
+// if the cache is full. The token is dropped if the cache has been purged since given generation (the token has
+// been validated with an outdated JWKS).
+func (c *tokenCache) add(sessionToken string, claims *entities.Claims, validUntil time.Time, generation uint64) {
+	key := sha256.Sum256([]byte(sessionToken))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*tokenCacheEntry)
+		entry.claims = *claims
+		entry.validUntil = validUntil
+		c.lru.MoveToFront(element)
+
+		return
+	}
+
+	if c.lru.Len() >= c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenCacheEntry).key)
+		c.evictions++
+	}
+
+	c.entries[key] = c.lru.PushFront(&tokenCacheEntry{
+		key:        key,
+		claims:     *claims,
+		validUntil: validUntil,
+	})
+}
+
+// purge removes all cached tokens, e.g. because the JWKS has changed
+func (c *tokenCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[[sha256.Size]byte]*list.Element, c.capacity)
+	c.lru.Init()
+	c.generation++
+}
+
+// stats returns the cache metrics
+func (c *tokenCache) stats() entities.TokenCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return entities.TokenCacheStats{
+		Capacity:  c.capacity,
+		Size:      c.lru.Len(),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
@@ -0,0 +1,32 @@
+package session
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+// startSpan starts a span for a session token validation
+func (i *Impl) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return i.tracer.Start(ctx, name, trace.WithAttributes(tracing.AttributeProjectID.String(i.Config.ProjectID)))
+}
+
+// endSpan records the result of a session token validation (the span has to be ended by the caller)
+func (i *Impl) endSpan(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	var validationErr *validationerror.ValidationError
+	if errors.As(err, &validationErr) {
+		span.SetAttributes(tracing.AttributeValidationCode.String(validationErr.Code.String()))
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
 	"github.com/corbado/corbado-go/v2/pkg/generated/common"
 	"github.com/corbado/corbado-go/v2/pkg/servererror"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
 )
 
 type User interface {
@@ -37,13 +38,13 @@ func New(client *api.ClientWithResponses) (*Impl, error) {
 
 // Create creates a new user
 func (i *Impl) Create(ctx context.Context, req api.UserCreateReq, editors ...api.RequestEditorFn) (*api.User, error) {
-	res, err := i.client.UserCreateWithResponse(ctx, req, editors...)
+	res, err := i.client.UserCreateWithResponse(tracing.WithOperation(ctx, "UserCreate"), req, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -56,13 +57,13 @@ func (i *Impl) CreateActiveByName(ctx context.Context, fullName string, editors
 		Status:   "active",
 	}
 
-	res, err := i.client.UserCreateWithResponse(ctx, req, editors...)
+	res, err := i.client.UserCreateWithResponse(tracing.WithOperation(ctx, "UserCreate"), req, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -70,13 +71,13 @@ func (i *Impl) CreateActiveByName(ctx context.Context, fullName string, editors
 
 // Get gets a user by ID
 func (i *Impl) Get(ctx context.Context, userID common.UserID, editors ...api.RequestEditorFn) (*api.User, error) {
-	res, err := i.client.UserGetWithResponse(ctx, userID, editors...)
+	res, err := i.client.UserGetWithResponse(tracing.WithOperation(ctx, "UserGet"), userID, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
@@ -84,13 +85,13 @@ func (i *Impl) Get(ctx context.Context, userID common.UserID, editors ...api.Req
 
 // Delete deletes a user by ID
 func (i *Impl) Delete(ctx context.Context, userID common.UserID, editors ...api.RequestEditorFn) (*common.GenericRsp, error) {
-	res, err := i.client.UserDeleteWithResponse(ctx, userID, editors...)
+	res, err := i.client.UserDeleteWithResponse(tracing.WithOperation(ctx, "UserDelete"), userID, editors...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	if res.JSONDefault != nil {
-		return nil, servererror.New(res.JSONDefault)
+		return nil, servererror.NewFromResponse(res.JSONDefault, res.HTTPResponse)
 	}
 
 	return res.JSON200, nil
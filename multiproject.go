@@ -0,0 +1,273 @@
+package corbado
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/internal/lifecycle"
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+// MultiProjectValidator validates session tokens of multiple projects (e.g. one per brand) in a single process.
+// Tokens are routed to the project by their issuer (iss), the session service (and JWKS) of a project is created
+// on first use. The project which validated a token is returned in User.ProjectID.
+type MultiProjectValidator struct {
+	projects []*project
+	tracker  *lifecycle.Tracker
+}
+
+type project struct {
+	config        *Config
+	httpClient    *http.Client
+	sessionConfig *session.Config
+
+	mu       sync.Mutex
+	sessions *session.Impl // nilable, created on first use
+}
+
+// NewMultiProjectValidator returns new multi project validator for given project configs, the given HTTP client
+// (nilable) is shared by all projects
+func NewMultiProjectValidator(httpClient *http.Client, configs ...*Config) (*MultiProjectValidator, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("At least one project config is required")
+	}
+
+	m := &MultiProjectValidator{
+		projects: make([]*project, 0, len(configs)),
+		tracker:  lifecycle.NewTracker(),
+	}
+
+	for _, config := range configs {
+		if err := assert.NotNil(config); err != nil {
+			return nil, err
+		}
+
+		if err := config.validate(); err != nil {
+			return nil, errors.WithMessagef(err, "Invalid config of project '%s' given", config.ProjectID)
+		}
+
+		if m.project(config.ProjectID) != nil {
+			return nil, errors.Errorf("Duplicate project '%s' given", config.ProjectID)
+		}
+
+		// Copy config to not modify the given one
+		projectConfig := *config
+		if httpClient != nil {
+			projectConfig.HTTPClient = httpClient
+			projectConfig.HTTPTransport = nil
+		}
+
+		projectHTTPClient := projectConfig.httpClient()
+		sessionConfig := newSessionConfig(&projectConfig, projectHTTPClient)
+
+		m.projects = append(m.projects, &project{
+			config:        &projectConfig,
+			httpClient:    projectHTTPClient,
+			sessionConfig: sessionConfig,
+		})
+	}
+
+	if err := m.checkIssuers(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ValidateToken validates the given session token with the project its issuer (iss) belongs to and returns the
+// user (see User.ProjectID for the project)
+func (m *MultiProjectValidator) ValidateToken(ctx context.Context, sessionToken string) (*entities.User, error) {
+	if err := assert.NotNil(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := assert.StringNotEmpty(sessionToken); err != nil {
+		return nil, err
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(sessionToken, claims); err != nil {
+		return nil, newRoutingError(errors.WithStack(err), err.Error(), sessionToken, validationerror.CodeJWTInvalidData)
+	}
+
+	if claims.Issuer == "" {
+		return nil, newRoutingError(nil, "Issuer is empty", sessionToken, validationerror.CodeJWTIssuerEmpty)
+	}
+
+	var matches []*project
+	for _, p := range m.projects {
+		if p.sessionConfig.AcceptsIssuer(claims.Issuer) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, newRoutingError(nil, fmt.Sprintf("No project found for issuer '%s'", claims.Issuer), sessionToken, validationerror.CodeJWTIssuerMismatch)
+	case 1:
+		return m.validateToken(ctx, matches[0], sessionToken)
+	default:
+		// Overlapping issuer patterns, the token is not routed to any of the projects
+		message := fmt.Sprintf("Issuer '%s' is accepted by projects '%s' and '%s'", claims.Issuer, matches[0].config.ProjectID, matches[1].config.ProjectID)
+
+		return nil, newRoutingError(nil, message, sessionToken, validationerror.CodeJWTIssuerMismatch)
+	}
+}
+
+// ValidateTokenForProject validates the given session token with the given project (instead of routing it by
+// its issuer)
+func (m *MultiProjectValidator) ValidateTokenForProject(ctx context.Context, projectID string, sessionToken string) (*entities.User, error) {
+	p := m.project(projectID)
+	if p == nil {
+		return nil, errors.Errorf("Unknown project '%s' given", projectID)
+	}
+
+	return m.validateToken(ctx, p, sessionToken)
+}
+
+// Close closes the session services of all projects (see SDK.Close)
+func (m *MultiProjectValidator) Close(ctx context.Context) error {
+	if err := assert.NotNil(ctx); err != nil {
+		return err
+	}
+
+	var errs closeErrors
+	if err := m.tracker.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Close all projects even if one fails to not leak their background JWKS refreshes
+	for _, p := range m.projects {
+		p.mu.Lock()
+		sessions := p.sessions
+		p.mu.Unlock()
+
+		if sessions == nil {
+			continue
+		}
+
+		if err := sessions.Close(ctx); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "Closing session service of project '%s' failed", p.config.ProjectID))
+		}
+	}
+
+	return errs.err()
+}
+
+func (m *MultiProjectValidator) validateToken(ctx context.Context, p *project, sessionToken string) (*entities.User, error) {
+	sessions, err := m.sessions(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions.ValidateTokenWithContext(ctx, sessionToken)
+}
+
+// sessions returns the session service of given project, it is created on first use
+func (m *MultiProjectValidator) sessions(p *project) (*session.Impl, error) {
+	if err := m.tracker.Acquire(); err != nil {
+		return nil, err
+	}
+	defer m.tracker.Release()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sessions != nil {
+		return p.sessions, nil
+	}
+
+	client, err := newClient(p.config, p.httpClient, m.tracker)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := session.New(client, p.sessionConfig)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Creating session service of project '%s' failed", p.config.ProjectID)
+	}
+
+	p.sessions = sessions
+
+	return sessions, nil
+}
+
+// checkIssuers returns an error if an issuer (exact or legacy) of a project is accepted by another project (exact
+// or by pattern), overlapping patterns of different projects are rejected when routing (see ValidateToken)
+func (m *MultiProjectValidator) checkIssuers() error {
+	for _, p := range m.projects {
+		for _, issuer := range p.sessionConfig.AcceptedIssuers() {
+			for _, other := range m.projects {
+				if other != p && other.sessionConfig.AcceptsIssuer(issuer) {
+					return errors.Errorf("Issuer '%s' is accepted by projects '%s' and '%s'", issuer, p.config.ProjectID, other.config.ProjectID)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *MultiProjectValidator) project(projectID string) *project {
+	for _, p := range m.projects {
+		if p.config.ProjectID == projectID {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// closeErrors are the errors which occurred while closing a multi project validator
+type closeErrors []error
+
+// err returns nil if there are no errors and the only error if there is one
+func (c closeErrors) err() error {
+	switch len(c) {
+	case 0:
+		return nil
+	case 1:
+		return c[0]
+	default:
+		return c
+	}
+}
+
+// Error implements error interface
+func (c closeErrors) Error() string {
+	messages := make([]string, len(c))
+	for i, err := range c {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Is implements errors.Is and matches if one of the errors matches
+func (c closeErrors) Is(target error) bool {
+	for _, err := range c {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newRoutingError returns a validation error for session tokens which could not be routed to a project
+func newRoutingError(err error, message string, sessionToken string, code validationerror.Code) error {
+	validationErr := validationerror.Wrap(err, fmt.Sprintf("JWT validation failed: '%s'", message), code)
+	validationErr.Reason = message
+	validationErr.TokenFingerprint = validationerror.Fingerprint(sessionToken)
+
+	return validationErr
+}
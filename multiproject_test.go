@@ -0,0 +1,195 @@
+package corbado
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+func newMultiProjectTestConfig(t *testing.T, projectID string, frontendAPI string) *Config {
+	jwks, err := os.ReadFile("tests/unit/testdata/jwks.json")
+	require.NoError(t, err)
+
+	config, err := NewConfig(projectID, "corbado1_secret", frontendAPI, "https://backendapi.cloud.corbado.io")
+	require.NoError(t, err)
+
+	config.JWKS = jwks
+
+	return config
+}
+
+func newMultiProjectTestToken(t *testing.T, issuer string) string {
+	privateKeyFile, err := os.ReadFile("tests/unit/testdata/validPrivateKey.pem")
+	require.NoError(t, err)
+
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyFile)
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"sub": "usr-1",
+	})
+	token.Header["kid"] = "kid123"
+
+	tokenString, err := token.SignedString(privateKey)
+	require.NoError(t, err)
+
+	return tokenString
+}
+
+func TestMultiProjectValidator(t *testing.T) {
+	validator, err := NewMultiProjectValidator(
+		nil,
+		newMultiProjectTestConfig(t, "pro-11111111", "https://pro-11111111.frontendapi.cloud.corbado.io"),
+		newMultiProjectTestConfig(t, "pro-22222222", "https://auth.brand2.com"),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		issuer            string
+		expectedProjectID string
+		validationErrCode validationerror.Code
+	}{
+		{
+			name:              "First project",
+			issuer:            "https://pro-11111111.frontendapi.cloud.corbado.io",
+			expectedProjectID: "pro-11111111",
+		},
+		{
+			name:              "Second project (CNAME)",
+			issuer:            "https://auth.brand2.com",
+			expectedProjectID: "pro-22222222",
+		},
+		{
+			name:              "Second project (legacy issuer)",
+			issuer:            "https://pro-22222222.frontendapi.corbado.io",
+			expectedProjectID: "pro-22222222",
+		},
+		{
+			name:              "Unknown issuer",
+			issuer:            "https://auth.brand3.com",
+			validationErrCode: validationerror.CodeJWTIssuerMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user, err := validator.ValidateToken(context.Background(), newMultiProjectTestToken(t, test.issuer))
+			if test.expectedProjectID == "" {
+				validationErr := AsValidationError(err)
+				require.NotNil(t, validationErr)
+				assert.Equal(t, test.validationErrCode, validationErr.Code)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedProjectID, user.ProjectID)
+			assert.Equal(t, "usr-1", user.UserID)
+		})
+	}
+
+	// Caller provided project
+	_, err = validator.ValidateTokenForProject(context.Background(), "pro-11111111", newMultiProjectTestToken(t, "https://auth.brand2.com"))
+	assert.ErrorIs(t, err, validationerror.ErrJWTIssuerMismatch)
+
+	_, err = validator.ValidateTokenForProject(context.Background(), "pro-33333333", newMultiProjectTestToken(t, "https://auth.brand2.com"))
+	assert.ErrorContains(t, err, "Unknown project")
+
+	_, err = validator.ValidateToken(context.Background(), "invalid")
+	assert.ErrorIs(t, err, validationerror.ErrJWTInvalidData)
+
+	require.NoError(t, validator.Close(context.Background()))
+
+	_, err = validator.ValidateToken(context.Background(), newMultiProjectTestToken(t, "https://auth.brand2.com"))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestNewMultiProjectValidator_Failure(t *testing.T) {
+	_, err := NewMultiProjectValidator(nil)
+	assert.Error(t, err)
+
+	_, err = NewMultiProjectValidator(
+		nil,
+		newMultiProjectTestConfig(t, "pro-11111111", "https://auth.brand.com"),
+		newMultiProjectTestConfig(t, "pro-11111111", "https://auth.brand.com"),
+	)
+	assert.ErrorContains(t, err, "Duplicate project")
+
+	_, err = NewMultiProjectValidator(
+		nil,
+		newMultiProjectTestConfig(t, "pro-11111111", "https://auth.brand.com"),
+		newMultiProjectTestConfig(t, "pro-22222222", "https://auth.brand.com"),
+	)
+	assert.ErrorContains(t, err, "is accepted by projects")
+
+	// Issuer pattern of one project matches the issuer of another project
+	patternConfig := newMultiProjectTestConfig(t, "pro-22222222", "https://auth.brand2.com")
+	patternConfig.JWTIssuerPatterns = []string{"*.brand.com"}
+
+	_, err = NewMultiProjectValidator(
+		nil,
+		newMultiProjectTestConfig(t, "pro-11111111", "https://auth.brand.com"),
+		patternConfig,
+	)
+	assert.ErrorContains(t, err, "is accepted by projects")
+}
+
+func TestMultiProjectValidator_OverlappingIssuerPatterns(t *testing.T) {
+	config1 := newMultiProjectTestConfig(t, "pro-11111111", "https://auth.brand1.com")
+	config1.JWTIssuerPatterns = []string{"*.acme.com"}
+
+	config2 := newMultiProjectTestConfig(t, "pro-22222222", "https://auth.brand2.com")
+	config2.JWTIssuerPatterns = []string{"login.*.com"}
+
+	validator, err := NewMultiProjectValidator(nil, config1, config2)
+	require.NoError(t, err)
+
+	// Matched by the pattern of a single project
+	user, err := validator.ValidateToken(context.Background(), newMultiProjectTestToken(t, "https://auth.acme.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "pro-11111111", user.ProjectID)
+
+	// Matched by the patterns of both projects
+	_, err = validator.ValidateToken(context.Background(), newMultiProjectTestToken(t, "https://login.acme.com"))
+	assert.ErrorIs(t, err, validationerror.ErrJWTIssuerMismatch)
+	assert.ErrorContains(t, err, "is accepted by projects")
+}
+
+func TestMultiProjectValidator_CloseExpiredContext(t *testing.T) {
+	validator, err := NewMultiProjectValidator(
+		nil,
+		newMultiProjectTestConfig(t, "pro-11111111", "https://auth.brand1.com"),
+		newMultiProjectTestConfig(t, "pro-22222222", "https://auth.brand2.com"),
+	)
+	require.NoError(t, err)
+
+	// Session services are created on first use
+	for _, issuer := range []string{"https://auth.brand1.com", "https://auth.brand2.com"} {
+		_, err := validator.ValidateToken(context.Background(), newMultiProjectTestToken(t, issuer))
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_ = validator.Close(ctx)
+
+	// All projects have been closed even if closing one of them failed
+	for _, p := range validator.projects {
+		require.NotNil(t, p.sessions)
+
+		_, err := p.sessions.ValidateTokenWithContext(context.Background(), newMultiProjectTestToken(t, p.sessionConfig.JWTIssuer))
+		assert.ErrorIs(t, err, ErrClosed, p.config.ProjectID)
+	}
+}
@@ -0,0 +1,173 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+)
+
+// ErrOpen is matched (errors.Is) by all OpenError errors
+var ErrOpen = errors.New("circuit breaker open")
+
+// State is the state of the circuit breaker of an endpoint group
+type State int
+
+const (
+	// StateClosed lets all requests pass
+	StateClosed State = iota
+
+	// StateOpen rejects all requests (fail fast)
+	StateOpen
+
+	// StateHalfOpen lets a limited number of probe requests pass to check if the Backend API has recovered
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(s))
+	}
+}
+
+// Settings configure the circuit breaker of an endpoint group
+type Settings struct {
+	// FailureThreshold is the number of consecutive failures (network errors or HTTP status codes 5xx) which
+	// open the circuit
+	FailureThreshold int
+
+	// OpenTimeout is the time the circuit stays open before probe requests are let through (half-open)
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of probe requests in half-open state, the circuit is closed again once all
+	// of them succeeded and opened again on the first failure
+	HalfOpenRequests int
+}
+
+// Config configures the circuit breaker, every endpoint group (see Group) has its own circuit
+type Config struct {
+	// Default settings used for all endpoint groups without explicit settings
+	Default Settings
+
+	// Groups contains the settings per endpoint group (e.g. "users" or "identifiers")
+	Groups map[string]Settings
+
+	// OnStateChange is called (synchronously) whenever the circuit of an endpoint group changes its state,
+	// e.g. to log or alert
+	OnStateChange func(group string, from State, to State)
+
+	// Clock returns the current time, defaults to time.Now (useful for deterministic tests)
+	Clock func() time.Time
+}
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// DefaultConfig returns the default config which opens the circuit of an endpoint group after five consecutive
+// failures for 30 seconds
+func DefaultConfig() *Config {
+	return &Config{
+		Default: Settings{
+			FailureThreshold: defaultFailureThreshold,
+			OpenTimeout:      defaultOpenTimeout,
+			HalfOpenRequests: defaultHalfOpenRequests,
+		},
+	}
+}
+
+// settings returns the settings of given endpoint group
+func (c *Config) settings(group string) Settings {
+	if settings, ok := c.Groups[group]; ok {
+		return settings
+	}
+
+	return c.Default
+}
+
+func (c *Config) validate() error {
+	if err := c.Default.validate(); err != nil {
+		return errors.WithMessage(err, "Invalid Default given")
+	}
+
+	for group, settings := range c.Groups {
+		if err := settings.validate(); err != nil {
+			return errors.WithMessagef(err, "Invalid Groups given (group %s)", group)
+		}
+	}
+
+	return nil
+}
+
+func (s *Settings) validate() error {
+	if s.FailureThreshold < 1 {
+		return errors.Errorf("assert failed: FailureThreshold must be positive (%d)", s.FailureThreshold)
+	}
+
+	if err := assert.DurationNotEmpty(s.OpenTimeout); err != nil {
+		return errors.WithMessage(err, "Invalid OpenTimeout given")
+	}
+
+	if s.HalfOpenRequests < 1 {
+		return errors.Errorf("assert failed: HalfOpenRequests must be positive (%d)", s.HalfOpenRequests)
+	}
+
+	return nil
+}
+
+// OpenError is returned without calling the Backend API if the circuit of the endpoint group is open
+type OpenError struct {
+	// Group is the endpoint group
+	Group string
+
+	// RetryAt is the time probe requests are let through again (zero if the circuit is half-open and all
+	// probe requests are in-flight)
+	RetryAt time.Time
+}
+
+// Error implements error interface
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s for endpoint group %s", ErrOpen.Error(), e.Group)
+}
+
+// Is makes errors.Is(err, ErrOpen) match
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// AsOpenError casts given error into an OpenError, if possible
+func AsOpenError(err error) *OpenError {
+	var openErr *OpenError
+	if !errors.As(err, &openErr) {
+		return nil
+	}
+
+	return openErr
+}
+
+// Group returns the endpoint group of given request path, which is the first path segment after the API version
+// (e.g. "users" for /v2/users/usr-1/identifiers)
+func Group(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range segments {
+		if segment == "v2" && i+1 < len(segments) {
+			return segments[i+1]
+		}
+	}
+
+	return segments[0]
+}
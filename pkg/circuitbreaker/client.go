@@ -0,0 +1,241 @@
+package circuitbreaker
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+)
+
+// Client rejects requests to endpoint groups whose circuit is open instead of passing them to the underlying doer
+type Client struct {
+	underlying api.HttpRequestDoer
+	config     *Config
+	clock      func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+var _ api.HttpRequestDoer = &Client{}
+
+type circuit struct {
+	settings  Settings
+	state     State
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+type transition struct {
+	from State
+	to   State
+}
+
+// NewClient returns new circuit breaking client which wraps given doer
+func NewClient(underlying api.HttpRequestDoer, config *Config) (*Client, error) {
+	if err := assert.NotNil(underlying, config); err != nil {
+		return nil, err
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	clock := config.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &Client{
+		underlying: underlying,
+		config:     config,
+		clock:      clock,
+		circuits:   make(map[string]*circuit),
+	}, nil
+}
+
+// Do implements HttpRequestDoer and executes HTTP request if the circuit of its endpoint group is not open
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := assert.NotNil(req); err != nil {
+		return nil, err
+	}
+
+	group := Group(req.URL.Path)
+
+	probe, err := c.allow(group)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := c.underlying.Do(req)
+	c.record(group, probe, classify(req, rsp, err))
+
+	return rsp, err
+}
+
+// State returns the current state of the circuit of given endpoint group
+func (c *Client) State(group string) State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	circuit, ok := c.circuits[group]
+	if !ok {
+		return StateClosed
+	}
+
+	if circuit.state == StateOpen && !c.clock().Before(circuit.openedAt.Add(circuit.settings.OpenTimeout)) {
+		return StateHalfOpen
+	}
+
+	return circuit.state
+}
+
+// allow returns an OpenError if the request must be rejected, otherwise whether the request is a probe request
+func (c *Client) allow(group string) (bool, error) {
+	c.mu.Lock()
+
+	circuit := c.circuit(group)
+	now := c.clock()
+
+	var transitions []transition
+	if circuit.state == StateOpen && !now.Before(circuit.openedAt.Add(circuit.settings.OpenTimeout)) {
+		transitions = append(transitions, circuit.transition(StateHalfOpen, now))
+	}
+
+	probe := false
+	var err error
+
+	switch circuit.state {
+	case StateOpen:
+		err = &OpenError{Group: group, RetryAt: circuit.openedAt.Add(circuit.settings.OpenTimeout)}
+	case StateHalfOpen:
+		if circuit.probes >= circuit.settings.HalfOpenRequests {
+			err = &OpenError{Group: group}
+		} else {
+			circuit.probes++
+			probe = true
+		}
+	case StateClosed:
+	}
+
+	c.mu.Unlock()
+	c.notify(group, transitions)
+
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return probe, nil
+}
+
+// record updates the circuit of given endpoint group with the outcome of a request
+func (c *Client) record(group string, probe bool, result outcome) {
+	c.mu.Lock()
+
+	circuit := c.circuit(group)
+	now := c.clock()
+
+	var transitions []transition
+
+	switch {
+	case probe && circuit.state == StateHalfOpen:
+		circuit.probes--
+
+		switch result {
+		case outcomeFailure:
+			transitions = append(transitions, circuit.transition(StateOpen, now))
+		case outcomeSuccess:
+			circuit.successes++
+			if circuit.successes >= circuit.settings.HalfOpenRequests {
+				transitions = append(transitions, circuit.transition(StateClosed, now))
+			}
+		case outcomeIgnored:
+		}
+	case !probe && circuit.state == StateClosed:
+		switch result {
+		case outcomeFailure:
+			circuit.failures++
+			if circuit.failures >= circuit.settings.FailureThreshold {
+				transitions = append(transitions, circuit.transition(StateOpen, now))
+			}
+		case outcomeSuccess:
+			circuit.failures = 0
+		case outcomeIgnored:
+		}
+	}
+
+	c.mu.Unlock()
+	c.notify(group, transitions)
+}
+
+// circuit returns the circuit of given endpoint group (must be called with mu held)
+func (c *Client) circuit(group string) *circuit {
+	existing, ok := c.circuits[group]
+	if ok {
+		return existing
+	}
+
+	created := &circuit{settings: c.config.settings(group)}
+	c.circuits[group] = created
+
+	return created
+}
+
+// notify calls the state change callback (must be called without mu held)
+func (c *Client) notify(group string, transitions []transition) {
+	if c.config.OnStateChange == nil {
+		return
+	}
+
+	for _, t := range transitions {
+		c.config.OnStateChange(group, t.from, t.to)
+	}
+}
+
+// transition changes the state of the circuit and resets its counters
+func (c *circuit) transition(to State, now time.Time) transition {
+	from := c.state
+
+	c.state = to
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+
+	if to == StateOpen {
+		c.openedAt = now
+	}
+
+	return transition{from: from, to: to}
+}
+
+// classify returns the outcome of a request, requests canceled by the caller are ignored
+func classify(req *http.Request, rsp *http.Response, err error) outcome {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || req.Context().Err() != nil {
+			return outcomeIgnored
+		}
+
+		return outcomeFailure
+	}
+
+	if rsp.StatusCode >= http.StatusInternalServerError {
+		return outcomeFailure
+	}
+
+	return outcomeSuccess
+}
@@ -0,0 +1,202 @@
+// Package corbadotest provides a fake Frontend API (JWKS endpoint) and mints session tokens so that code using
+// the Corbado Go SDK (e.g. authenticated HTTP handlers) can be tested without network access.
+package corbadotest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/corbado/corbado-go/v2"
+)
+
+const (
+	// DefaultProjectID is the project ID of the server (see WithProjectID)
+	DefaultProjectID = "pro-12345678"
+
+	// DefaultUserID is the subject (sub) of minted tokens (see WithSubject)
+	DefaultUserID = "usr-1234567890"
+
+	// DefaultTokenLifetime is the lifetime of minted tokens (see WithExpiry)
+	DefaultTokenLifetime = 5 * time.Minute
+
+	apiSecret = "corbado1_corbadotest"
+	jwksPath  = "/.well-known/jwks"
+	keySize   = 2048
+
+	jwksRefreshRateLimit = time.Millisecond
+)
+
+// Server is a fake Frontend API serving a JWKS, all other requests are passed to the Backend API handler (see
+// WithBackendAPIHandler)
+type Server struct {
+	// URL is the URL of the server (Frontend and Backend API) and the issuer of minted tokens
+	URL string
+
+	// ProjectID is the project ID of the server
+	ProjectID string
+
+	tb         testing.TB
+	server     *httptest.Server
+	backendAPI http.Handler
+
+	mu         sync.RWMutex
+	privateKey *rsa.PrivateKey
+	keyID      string
+	keyCount   int
+}
+
+// ServerOption configures the server
+type ServerOption func(s *Server)
+
+// WithProjectID sets the project ID (defaults to DefaultProjectID)
+func WithProjectID(projectID string) ServerOption {
+	return func(s *Server) {
+		s.ProjectID = projectID
+	}
+}
+
+// WithBackendAPIHandler sets the handler for Backend API requests (e.g. to test ValidateTokenStrict), by default
+// all Backend API requests fail with 404 Not Found
+func WithBackendAPIHandler(handler http.Handler) ServerOption {
+	return func(s *Server) {
+		s.backendAPI = handler
+	}
+}
+
+// NewServer generates an RSA key pair and starts a server serving the JWKS, the server is closed at the end of
+// the test
+func NewServer(tb testing.TB, opts ...ServerOption) *Server {
+	tb.Helper()
+
+	s := &Server{
+		ProjectID:  DefaultProjectID,
+		tb:         tb,
+		backendAPI: http.NotFoundHandler(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.RotateKey()
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	tb.Cleanup(s.server.Close)
+
+	return s
+}
+
+// Config returns a config pointing at the server (Frontend and Backend API), the JWKS refresh rate limit is
+// lowered so that key rotations (see RotateKey) are picked up right away
+func (s *Server) Config() *corbado.Config {
+	s.tb.Helper()
+
+	config, err := corbado.NewConfig(s.ProjectID, apiSecret, s.URL, s.URL)
+	if err != nil {
+		s.tb.Fatalf("corbadotest: creating config failed: %s", err.Error())
+	}
+
+	config.JWKSRefreshRateLimit = jwksRefreshRateLimit
+
+	return config
+}
+
+// NewSDK returns a SDK pointing at the server, it is closed at the end of the test
+func (s *Server) NewSDK() *corbado.Impl {
+	s.tb.Helper()
+
+	sdk, err := corbado.NewSDK(s.Config())
+	if err != nil {
+		s.tb.Fatalf("corbadotest: creating SDK failed: %s", err.Error())
+	}
+
+	s.tb.Cleanup(func() {
+		_ = sdk.Close(context.Background())
+	})
+
+	return sdk
+}
+
+// RotateKey replaces the signing key by a new one (with a new key ID), tokens minted before are not valid anymore
+func (s *Server) RotateKey() {
+	s.tb.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		s.tb.Fatalf("corbadotest: generating RSA key failed: %s", err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keyCount++
+	s.privateKey = privateKey
+	s.keyID = "corbadotest-" + strconv.Itoa(s.keyCount)
+}
+
+// JWKS returns the JWKS (JSON) served by the server
+func (s *Server) JWKS() []byte {
+	s.tb.Helper()
+
+	data, err := s.marshalJWKS()
+	if err != nil {
+		s.tb.Fatalf("corbadotest: marshalling JWKS failed: %s", err.Error())
+	}
+
+	return data
+}
+
+// marshalJWKS returns the JWKS (JSON) of the current signing key, it does not fail the test so that it can be
+// called from the handler goroutine
+func (s *Server) marshalJWKS() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"alg": jwt.SigningMethodRS256.Alg(),
+				"use": "sig",
+				"kid": s.keyID,
+				"n":   base64.RawURLEncoding.EncodeToString(s.privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.privateKey.E)).Bytes()),
+			},
+		},
+	}
+
+	return json.Marshal(jwks)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != jwksPath {
+		s.backendAPI.ServeHTTP(w, r)
+
+		return
+	}
+
+	data, err := s.marshalJWKS()
+	if err != nil {
+		s.tb.Errorf("corbadotest: marshalling JWKS failed: %s", err.Error())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
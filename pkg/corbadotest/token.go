@@ -0,0 +1,90 @@
+package corbadotest
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TokenOption configures a minted session token
+type TokenOption func(claims jwt.MapClaims)
+
+// WithSubject sets the subject (sub), defaults to DefaultUserID
+func WithSubject(userID string) TokenOption {
+	return func(claims jwt.MapClaims) {
+		claims["sub"] = userID
+	}
+}
+
+// WithIssuer sets the issuer (iss), defaults to the server URL
+func WithIssuer(issuer string) TokenOption {
+	return func(claims jwt.MapClaims) {
+		claims["iss"] = issuer
+	}
+}
+
+// WithIssuedAt sets the issued at time (iat) and not before time (nbf), defaults to now
+func WithIssuedAt(issuedAt time.Time) TokenOption {
+	return func(claims jwt.MapClaims) {
+		claims["iat"] = issuedAt.Unix()
+		claims["nbf"] = issuedAt.Unix()
+	}
+}
+
+// WithExpiry sets the expiration time (exp), defaults to now plus DefaultTokenLifetime
+func WithExpiry(expiresAt time.Time) TokenOption {
+	return func(claims jwt.MapClaims) {
+		claims["exp"] = expiresAt.Unix()
+	}
+}
+
+// WithClaim sets an arbitrary claim (e.g. project specific custom claims), nil removes the claim
+func WithClaim(name string, value any) TokenOption {
+	return func(claims jwt.MapClaims) {
+		if value == nil {
+			delete(claims, name)
+
+			return
+		}
+
+		claims[name] = value
+	}
+}
+
+// MintToken returns a session token signed with the current key of the server. By default the token is valid
+// for DefaultTokenLifetime and contains the claims of DefaultUserID (see TokenOption to change them).
+func (s *Server) MintToken(opts ...TokenOption) string {
+	s.tb.Helper()
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":          s.URL,
+		"sub":          DefaultUserID,
+		"iat":          now.Unix(),
+		"nbf":          now.Unix(),
+		"exp":          now.Add(DefaultTokenLifetime).Unix(),
+		"name":         "Test User",
+		"email":        "test@example.com",
+		"phone_number": "+4915112345678",
+		"orig":         "test@example.com",
+	}
+
+	for _, opt := range opts {
+		opt(claims)
+	}
+
+	s.mu.RLock()
+	privateKey := s.privateKey
+	keyID := s.keyID
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		s.tb.Fatalf("corbadotest: signing token failed: %s", err.Error())
+	}
+
+	return tokenString
+}
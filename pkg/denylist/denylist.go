@@ -0,0 +1,28 @@
+package denylist
+
+import (
+	"context"
+	"time"
+)
+
+// Token contains the claims of a (valid) session token which are relevant for revocation
+type Token struct {
+	// ID is the token ID (jti claim), empty if the token has none
+	ID string
+
+	// UserID is the subject (sub claim)
+	UserID string
+
+	// IssuedAt is the issued at time (iat claim), zero if the token has none
+	IssuedAt time.Time
+
+	// ExpiresAt is the expiration time (exp claim), zero if the token has none
+	ExpiresAt time.Time
+}
+
+// Store is consulted for every session token which is otherwise valid, it can be implemented on top of a
+// shared database (e.g. Redis) to revoke tokens across multiple instances
+type Store interface {
+	// IsRevoked returns true if the given token has been revoked (by token ID, user or issued before timestamp)
+	IsRevoked(ctx context.Context, token *Token) (bool, error)
+}
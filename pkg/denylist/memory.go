@@ -0,0 +1,107 @@
+package denylist
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Store, revocations are lost on restart and not shared between instances
+type Memory struct {
+	mu sync.RWMutex
+
+	// tokens maps token IDs to the time their revocation can be forgotten (token expiration)
+	tokens map[string]time.Time
+
+	users         map[string]struct{}
+	issuedBefores map[string]time.Time
+
+	now func() time.Time
+}
+
+var _ Store = &Memory{}
+
+// NewMemory returns new in-memory store
+func NewMemory() *Memory {
+	return &Memory{
+		tokens:        make(map[string]time.Time),
+		users:         make(map[string]struct{}),
+		issuedBefores: make(map[string]time.Time),
+		now:           time.Now,
+	}
+}
+
+// RevokeToken revokes the token with given ID (jti). The revocation is kept until expiresAt (the expiration of
+// the token), zero keeps it forever.
+func (m *Memory) RevokeToken(tokenID string, expiresAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpired()
+	m.tokens[tokenID] = expiresAt
+}
+
+// RevokeUser revokes all tokens of given user (sub) until RestoreUser is called, e.g. because the user has been
+// disabled
+func (m *Memory) RevokeUser(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[userID] = struct{}{}
+}
+
+// RestoreUser undoes RevokeUser
+func (m *Memory) RestoreUser(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, userID)
+}
+
+// RevokeUserTokensIssuedBefore revokes all tokens of given user (sub) issued before given time, e.g. to log out
+// the user everywhere. Tokens without issued at (iat) are revoked as well.
+func (m *Memory) RevokeUserTokensIssuedBefore(userID string, issuedBefore time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.issuedBefores[userID]; ok && current.After(issuedBefore) {
+		return
+	}
+
+	m.issuedBefores[userID] = issuedBefore
+}
+
+// IsRevoked implements Store
+func (m *Memory) IsRevoked(_ context.Context, token *Token) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if token.ID != "" {
+		if _, ok := m.tokens[token.ID]; ok {
+			return true, nil
+		}
+	}
+
+	if _, ok := m.users[token.UserID]; ok {
+		return true, nil
+	}
+
+	if issuedBefore, ok := m.issuedBefores[token.UserID]; ok {
+		if token.IssuedAt.IsZero() || token.IssuedAt.Before(issuedBefore) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// removeExpired removes revoked token IDs of expired tokens (must be called with mu held)
+func (m *Memory) removeExpired() {
+	now := m.now()
+
+	for tokenID, expiresAt := range m.tokens {
+		if !expiresAt.IsZero() && now.After(expiresAt) {
+			delete(m.tokens, tokenID)
+		}
+	}
+}
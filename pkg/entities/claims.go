@@ -9,4 +9,26 @@ type Claims struct {
 	Email       string `json:"email,omitempty"`
 	PhoneNumber string `json:"phone_number,omitempty"`
 	Version     int    `json:"version,omitempty"`
+
+	// SessionID is the ID of the long session the session token has been issued for (if contained)
+	SessionID string `json:"sid,omitempty"`
+
+	// AuthTime is the time the user authenticated (if contained), see stepup.AuthenticatedAt
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+}
+
+// CustomClaims is implemented by all structs embedding Claims and can be used to decode session tokens with
+// project specific claims
+type CustomClaims interface {
+	jwt.Claims
+	CorbadoClaims() *Claims
+}
+
+// ClaimsValidator validates (custom) claims of an otherwise valid session token, for example to enforce
+// business rules
+type ClaimsValidator func(claims CustomClaims) error
+
+// CorbadoClaims implements CustomClaims and returns the Corbado specific claims
+func (c *Claims) CorbadoClaims() *Claims {
+	return c
 }
@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+// KeySetStatus describes the JWKS currently used to validate session tokens, zero times mean "never" (or "not
+// scheduled" for NextRefresh)
+type KeySetStatus struct {
+	// Ready is true if the JWKS has been loaded
+	Ready bool `json:"ready"`
+
+	// Source is the source of the JWKS ("http", "file" or "static")
+	Source string `json:"source"`
+
+	Keys        []KeyStatus `json:"keys"`
+	LastRefresh time.Time   `json:"lastRefresh"`
+	NextRefresh time.Time   `json:"nextRefresh"`
+
+	// LastError is the error of the last failed refresh (empty if no refresh has failed yet)
+	LastError   string    `json:"lastError,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt"`
+}
+
+// KeyStatus describes a single key of the JWKS
+type KeyStatus struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// Thumbprint is the RFC 7638 (SHA-256, base64url encoded) thumbprint of the key which can be used for
+	// key pinning
+	Thumbprint string `json:"thumbprint,omitempty"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+// TokenCacheStats contains the metrics of the validated session token cache
+type TokenCacheStats struct {
+	// Capacity is the maximum number of cached tokens (zero if the cache is disabled)
+	Capacity int
+	Size     int
+
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// HitRate returns the ratio of cache hits to all lookups (zero if there have been no lookups)
+func (s TokenCacheStats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(lookups)
+}
@@ -1,6 +1,68 @@
 package entities
 
+import "time"
+
 type User struct {
+	// ProjectID is the ID of the project the session token has been validated for
+	ProjectID string
+
 	UserID   string
 	FullName string
+
+	Email       string
+	PhoneNumber string
+
+	// LoginIdentifier is the identifier (email address, phone number or username) the user logged in with (orig claim)
+	LoginIdentifier string
+
+	Issuer    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	NotBefore time.Time
+	Version   int
+
+	// SessionToken is the raw session token (JWT) the user has been created from
+	SessionToken string
+
+	// Claims contains all (standard and Corbado specific) claims of the session token
+	Claims *Claims
+}
+
+// NewUser returns new user from given claims and session token
+func NewUser(claims *Claims, sessionToken string) *User {
+	user := &User{
+		UserID:          claims.Subject,
+		FullName:        claims.Name,
+		Email:           claims.Email,
+		PhoneNumber:     claims.PhoneNumber,
+		LoginIdentifier: claims.Orig,
+		Issuer:          claims.Issuer,
+		Version:         claims.Version,
+		SessionToken:    sessionToken,
+		Claims:          claims,
+	}
+
+	if claims.IssuedAt != nil {
+		user.IssuedAt = claims.IssuedAt.Time
+	}
+
+	if claims.ExpiresAt != nil {
+		user.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	if claims.NotBefore != nil {
+		user.NotBefore = claims.NotBefore.Time
+	}
+
+	return user
+}
+
+// RemainingLifetime returns the time until the session token expires (relative to given time), it returns zero
+// if the session token has already expired or has no expiry
+func (u *User) RemainingLifetime(now time.Time) time.Duration {
+	if u.ExpiresAt.IsZero() || !u.ExpiresAt.After(now) {
+		return 0
+	}
+
+	return u.ExpiresAt.Sub(now)
 }
@@ -0,0 +1,203 @@
+package grpcmiddleware
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/corbado/corbado-go/v2"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/middleware"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+const (
+	// DefaultMetadataKey is the metadata key the session token is read from (besides authorization)
+	DefaultMetadataKey = "cbo_short_session"
+
+	authorizationMetadataKey = "authorization"
+)
+
+// SessionValidator validates session tokens, it is implemented by SDK.Sessions()
+type SessionValidator interface {
+	ValidateTokenWithContext(ctx context.Context, sessionToken string) (*entities.User, error)
+}
+
+type options struct {
+	metadataKeys            []string
+	unauthenticatedMethods  map[string]struct{}
+	unauthenticatedServices map[string]struct{}
+}
+
+// Option configures the session interceptors
+type Option func(o *options)
+
+// WithMetadataKeys replaces the default metadata keys (authorization and cbo_short_session) the session token is
+// read from, keys are tried in the given order. Values of the authorization key must use the Bearer scheme.
+func WithMetadataKeys(keys ...string) Option {
+	return func(o *options) {
+		o.metadataKeys = make([]string, len(keys))
+		for i, key := range keys {
+			o.metadataKeys[i] = strings.ToLower(key)
+		}
+	}
+}
+
+// WithUnauthenticatedMethods allows given methods to be called without session token. Methods are given as full
+// method names (e.g. "/package.Service/Method"), all methods of a service can be allowed with "/package.Service/*".
+func WithUnauthenticatedMethods(fullMethods ...string) Option {
+	return func(o *options) {
+		for _, fullMethod := range fullMethods {
+			if strings.HasSuffix(fullMethod, "/*") {
+				o.unauthenticatedServices[strings.TrimSuffix(fullMethod, "*")] = struct{}{}
+			} else {
+				o.unauthenticatedMethods[fullMethod] = struct{}{}
+			}
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		metadataKeys:            []string{authorizationMetadataKey, DefaultMetadataKey},
+		unauthenticatedMethods:  map[string]struct{}{},
+		unauthenticatedServices: map[string]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+func (o *options) isUnauthenticated(fullMethod string) bool {
+	if _, ok := o.unauthenticatedMethods[fullMethod]; ok {
+		return true
+	}
+
+	if pos := strings.LastIndex(fullMethod, "/"); pos >= 0 {
+		if _, ok := o.unauthenticatedServices[fullMethod[:pos+1]]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnaryServerInterceptor returns an interceptor which validates the session token of every unary RPC and
+// stores the user in the context (see UserFromContext)
+func UnaryServerInterceptor(sessions SessionValidator, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if o.isUnauthenticated(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, sessions, o)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor which validates the session token of every streaming RPC and
+// stores the user in the stream context (see UserFromContext)
+func StreamServerInterceptor(sessions SessionValidator, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if o.isUnauthenticated(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		ctx, err := authenticate(stream.Context(), sessions, o)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// UserFromContext returns the user stored by the session interceptors
+func UserFromContext(ctx context.Context) (*entities.User, bool) {
+	return middleware.UserFromContext(ctx)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context holding the user
+func (a *authenticatedStream) Context() context.Context {
+	return a.ctx
+}
+
+func authenticate(ctx context.Context, sessions SessionValidator, o *options) (context.Context, error) {
+	sessionToken := extractToken(ctx, o.metadataKeys)
+	if sessionToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "no session token found in metadata")
+	}
+
+	user, err := sessions.ValidateTokenWithContext(ctx, sessionToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return middleware.ContextWithUser(ctx, user), nil
+}
+
+func extractToken(ctx context.Context, metadataKeys []string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	for _, key := range metadataKeys {
+		for _, value := range md.Get(key) {
+			value = strings.TrimSpace(value)
+
+			if key == authorizationMetadataKey {
+				if len(value) < 7 || !strings.EqualFold(value[:7], "Bearer ") {
+					continue
+				}
+
+				value = strings.TrimSpace(value[7:])
+			}
+
+			if value != "" {
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
+// toStatusError maps validation errors to gRPC status errors, details of unexpected errors are not exposed
+func toStatusError(err error) error {
+	validationErr := corbado.AsValidationError(err)
+	if validationErr == nil {
+		return status.Error(codes.Internal, "session token could not be validated")
+	}
+
+	switch validationErr.Code {
+	case validationerror.CodeTimeout:
+		return status.Error(codes.DeadlineExceeded, "session token validation timed out")
+
+	case validationerror.CodeCanceled:
+		return status.Error(codes.Canceled, "session token validation canceled")
+
+	default:
+		return status.Error(codes.Unauthenticated, "invalid session token")
+	}
+}
@@ -0,0 +1,158 @@
+package grpcmiddleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/grpcmiddleware"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+type sessionValidatorMock struct{}
+
+func (s *sessionValidatorMock) ValidateTokenWithContext(_ context.Context, sessionToken string) (*entities.User, error) {
+	switch sessionToken {
+	case "valid":
+		return &entities.User{UserID: "usr-1234567890"}, nil
+	case "expired":
+		return nil, validationerror.New("expired", validationerror.CodeJWTExpired)
+	case "timeout":
+		return nil, validationerror.New("timeout", validationerror.CodeTimeout)
+	default:
+		return nil, errors.New("JWKS not reachable")
+	}
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStreamMock) Context() context.Context {
+	return s.ctx
+}
+
+func unaryHandler(ctx context.Context, _ any) (any, error) {
+	user, ok := grpcmiddleware.UserFromContext(ctx)
+	if !ok {
+		return "anonymous", nil
+	}
+
+	return user.UserID, nil
+}
+
+// nolint:funlen
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := grpcmiddleware.UnaryServerInterceptor(
+		&sessionValidatorMock{},
+		grpcmiddleware.WithUnauthenticatedMethods("/test.Service/Public", "/test.Health/*"),
+	)
+
+	tests := []struct {
+		name         string
+		fullMethod   string
+		metadata     metadata.MD
+		expectedCode codes.Code
+		expectedRsp  any
+	}{
+		{
+			name:         "No token",
+			fullMethod:   "/test.Service/Private",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Valid bearer token",
+			fullMethod:   "/test.Service/Private",
+			metadata:     metadata.Pairs("authorization", "Bearer valid"),
+			expectedCode: codes.OK,
+			expectedRsp:  "usr-1234567890",
+		},
+		{
+			name:         "Valid token in default metadata key",
+			fullMethod:   "/test.Service/Private",
+			metadata:     metadata.Pairs(grpcmiddleware.DefaultMetadataKey, "valid"),
+			expectedCode: codes.OK,
+			expectedRsp:  "usr-1234567890",
+		},
+		{
+			name:         "Authorization without bearer scheme",
+			fullMethod:   "/test.Service/Private",
+			metadata:     metadata.Pairs("authorization", "valid"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Expired token",
+			fullMethod:   "/test.Service/Private",
+			metadata:     metadata.Pairs("authorization", "Bearer expired"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Validation timeout",
+			fullMethod:   "/test.Service/Private",
+			metadata:     metadata.Pairs("authorization", "Bearer timeout"),
+			expectedCode: codes.DeadlineExceeded,
+		},
+		{
+			name:         "Unexpected error",
+			fullMethod:   "/test.Service/Private",
+			metadata:     metadata.Pairs("authorization", "Bearer other"),
+			expectedCode: codes.Internal,
+		},
+		{
+			name:         "Unauthenticated method",
+			fullMethod:   "/test.Service/Public",
+			expectedCode: codes.OK,
+			expectedRsp:  "anonymous",
+		},
+		{
+			name:         "Unauthenticated service",
+			fullMethod:   "/test.Health/Check",
+			expectedCode: codes.OK,
+			expectedRsp:  "anonymous",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.metadata)
+			}
+
+			rsp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.fullMethod}, unaryHandler)
+			assert.Equal(t, test.expectedCode, status.Code(err))
+			assert.Equal(t, test.expectedRsp, rsp)
+		})
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := grpcmiddleware.StreamServerInterceptor(&sessionValidatorMock{}, grpcmiddleware.WithMetadataKeys("X-Session"))
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+
+	var userID string
+	handler := func(_ any, stream grpc.ServerStream) error {
+		user, ok := grpcmiddleware.UserFromContext(stream.Context())
+		require.True(t, ok)
+		userID = user.UserID
+
+		return nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-session", "valid"))
+	require.NoError(t, interceptor(nil, &serverStreamMock{ctx: ctx}, info, handler))
+	assert.Equal(t, "usr-1234567890", userID)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid"))
+	err := interceptor(nil, &serverStreamMock{ctx: ctx}, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
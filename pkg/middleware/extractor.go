@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCookieName is the name of the cookie the Corbado web components store the session token in
+const DefaultCookieName = "cbo_short_session"
+
+// TokenExtractor extracts the session token from a request, it returns an empty string if the request does not
+// contain a session token
+type TokenExtractor func(r *http.Request) (string, error)
+
+// CookieExtractor extracts the session token from the cookie with given name
+func CookieExtractor(name string) TokenExtractor {
+	return func(r *http.Request) (string, error) {
+		cookie, err := r.Cookie(name)
+		if errors.Is(err, http.ErrNoCookie) {
+			return "", nil
+		}
+
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		return cookie.Value, nil
+	}
+}
+
+// HeaderExtractor extracts the session token from the header with given name
+func HeaderExtractor(name string) TokenExtractor {
+	return func(r *http.Request) (string, error) {
+		return strings.TrimSpace(r.Header.Get(name)), nil
+	}
+}
+
+// BearerExtractor extracts the session token from the Authorization header (Bearer scheme)
+func BearerExtractor() TokenExtractor {
+	return func(r *http.Request) (string, error) {
+		header := r.Header.Get("Authorization")
+		if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+			return "", nil
+		}
+
+		return strings.TrimSpace(header[7:]), nil
+	}
+}
+
+func extractToken(r *http.Request, extractors []TokenExtractor) (string, error) {
+	for _, extractor := range extractors {
+		token, err := extractor(r)
+		if err != nil {
+			return "", err
+		}
+
+		if token != "" {
+			return token, nil
+		}
+	}
+
+	return "", errors.WithStack(ErrNoSessionToken)
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/stepup"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+// ErrNoSessionToken is passed to the error responder if no session token could be extracted from the request
+var ErrNoSessionToken = errors.New("no session token found in request")
+
+type contextKey struct{}
+
+var userContextKey = contextKey{}
+
+// ErrorResponder writes the response for requests which could not be authenticated
+type ErrorResponder func(w http.ResponseWriter, r *http.Request, err error)
+
+type options struct {
+	extractors     []TokenExtractor
+	errorResponder ErrorResponder
+	strict         bool
+	clock          func() time.Time
+}
+
+// Option configures the session middlewares
+type Option func(o *options)
+
+// WithTokenExtractors replaces the default token extractors (cookie cbo_short_session and Authorization header),
+// extractors are tried in the given order
+func WithTokenExtractors(extractors ...TokenExtractor) Option {
+	return func(o *options) {
+		o.extractors = extractors
+	}
+}
+
+// WithErrorResponder replaces the default error responder (see DefaultErrorResponder)
+func WithErrorResponder(errorResponder ErrorResponder) Option {
+	return func(o *options) {
+		o.errorResponder = errorResponder
+	}
+}
+
+// WithStrictValidation additionally checks through the Backend API that the user and long session are still
+// active (see Sessions().ValidateTokenStrict), e.g. for high-risk routes like payments
+func WithStrictValidation() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithClock sets the clock used by RequireStepUp (defaults to time.Now)
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		extractors:     []TokenExtractor{CookieExtractor(DefaultCookieName), BearerExtractor()},
+		errorResponder: DefaultErrorResponder,
+		clock:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// RequireSession returns a middleware which validates the session token of every request and rejects requests
+// without a valid one. The user is stored in the request context (see UserFromContext).
+func RequireSession(sdk corbado.SDK, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := authenticate(r, sdk, o)
+			if err != nil {
+				o.errorResponder(w, r, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+		})
+	}
+}
+
+// OptionalSession returns a middleware which validates the session token of every request (if there is one) and
+// stores the user in the request context (see UserFromContext). Requests without or with an invalid session token
+// are passed on without a user, unexpected errors and validations which timed out or were canceled are passed to
+// the error responder.
+func OptionalSession(sdk corbado.SDK, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := authenticate(r, sdk, o)
+			switch {
+			case err == nil:
+				r = r.WithContext(ContextWithUser(r.Context(), user))
+
+			case isUnauthenticated(err):
+				// Anonymous request
+
+			default:
+				o.errorResponder(w, r, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireStepUp returns a middleware which rejects requests of users who authenticated longer than maxAge ago
+// with a stepup.ReauthenticationRequiredError (passed to the error responder). It must be used after
+// RequireSession, requests without user are rejected with ErrNoSessionToken.
+func RequireStepUp(maxAge time.Duration, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := UserFromContext(r.Context())
+			if !ok {
+				o.errorResponder(w, r, ErrNoSessionToken)
+
+				return
+			}
+
+			if err := stepup.Require(user, maxAge, o.clock()); err != nil {
+				o.errorResponder(w, r, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func authenticate(r *http.Request, sdk corbado.SDK, o *options) (*entities.User, error) {
+	sessionToken, err := extractToken(r, o.extractors)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.strict {
+		return sdk.Sessions().ValidateTokenStrict(r.Context(), sessionToken)
+	}
+
+	return sdk.Sessions().ValidateTokenWithContext(r.Context(), sessionToken)
+}
+
+// ContextWithUser returns a copy of given context which holds the given user
+func ContextWithUser(ctx context.Context, user *entities.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the user stored by RequireSession or OptionalSession
+func UserFromContext(ctx context.Context) (*entities.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*entities.User)
+
+	return user, ok && user != nil
+}
+
+type stepUpResponse struct {
+	Error  string `json:"error"`
+	MaxAge int64  `json:"max_age"`
+}
+
+// DefaultErrorResponder responds with 401 Unauthorized if the session token is missing or invalid, with 503
+// Service Unavailable if the validation timed out and with 500 Internal Server Error otherwise (details are not
+// exposed). If reauthentication is required (see
+// RequireStepUp) the response contains a step-up challenge (RFC 9470) in the WWW-Authenticate header and
+// a JSON body (error and max_age in seconds) the frontend can react to.
+func DefaultErrorResponder(w http.ResponseWriter, _ *http.Request, err error) {
+	if reauthErr := stepup.AsReauthenticationRequiredError(err); reauthErr != nil {
+		maxAge := int64(reauthErr.MaxAge / time.Second)
+
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s", error_description="Reauthentication required", max_age=%d`, stepup.ErrorCode, maxAge))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(stepUpResponse{Error: stepup.ErrorCode, MaxAge: maxAge})
+
+		return
+	}
+
+	switch {
+	case isUnauthenticated(err):
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+	case errors.Is(err, validationerror.ErrTimeout):
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// isUnauthenticated returns true if the session token is missing or invalid, validations which timed out or were
+// canceled say nothing about the session token
+func isUnauthenticated(err error) bool {
+	if errors.Is(err, validationerror.ErrTimeout) || errors.Is(err, validationerror.ErrCanceled) {
+		return false
+	}
+
+	return errors.Is(err, ErrNoSessionToken) || corbado.IsValidationError(err)
+}
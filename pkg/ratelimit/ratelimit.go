@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+)
+
+// Config limits the requests sent to the Backend API, the zero value disables all limits
+type Config struct {
+	// RequestsPerSecond is the rate of the token bucket, zero disables rate limiting
+	RequestsPerSecond float64
+
+	// Burst is the size of the token bucket (number of requests which may be sent at once), defaults to one
+	Burst int
+
+	// MaxInFlight is the maximum number of concurrent requests, zero disables the cap
+	MaxInFlight int
+}
+
+func (c *Config) validate() error {
+	if math.IsNaN(c.RequestsPerSecond) || c.RequestsPerSecond < 0 {
+		return errors.Errorf("assert failed: RequestsPerSecond must not be negative (%f)", c.RequestsPerSecond)
+	}
+
+	if err := assert.IntNotNegative(c.Burst); err != nil {
+		return errors.WithMessage(err, "Invalid Burst given")
+	}
+
+	if err := assert.IntNotNegative(c.MaxInFlight); err != nil {
+		return errors.WithMessage(err, "Invalid MaxInFlight given")
+	}
+
+	return nil
+}
+
+// Client waits (respecting the request context) until a request may be sent according to the configured limits
+// before passing it to the underlying doer
+type Client struct {
+	underlying api.HttpRequestDoer
+	bucket     *bucket
+	inFlight   chan struct{}
+}
+
+var _ api.HttpRequestDoer = &Client{}
+
+// NewClient returns new rate limiting client which wraps given doer, all requests passed to the client share the
+// limits
+func NewClient(underlying api.HttpRequestDoer, config Config) (*Client, error) {
+	if err := assert.NotNil(underlying); err != nil {
+		return nil, err
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		underlying: underlying,
+	}
+
+	if config.RequestsPerSecond > 0 {
+		burst := config.Burst
+		if burst == 0 {
+			burst = 1
+		}
+
+		client.bucket = newBucket(config.RequestsPerSecond, burst, time.Now())
+	}
+
+	if config.MaxInFlight > 0 {
+		client.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+
+	return client, nil
+}
+
+// Do implements HttpRequestDoer and executes HTTP request once the limits allow it
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := assert.NotNil(req); err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+
+	if c.bucket != nil {
+		if err := c.bucket.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.inFlight != nil {
+		select {
+		case c.inFlight <- struct{}{}:
+			defer func() { <-c.inFlight }()
+		case <-ctx.Done():
+			return nil, errors.WithMessage(ctx.Err(), "waiting for in-flight request slot")
+		}
+	}
+
+	return c.underlying.Do(req)
+}
+
+// bucket is a token bucket which refills with given rate up to given burst
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// wait takes a token, waiting until one is available or the context is done
+func (b *bucket) wait(ctx context.Context) error {
+	wait := b.reserve(time.Now())
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+
+		return errors.WithMessage(ctx.Err(), "waiting for rate limit")
+	}
+}
+
+// reserve takes a token (the bucket may become negative) and returns the time until it is available
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token which has not been used
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
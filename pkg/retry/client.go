@@ -0,0 +1,210 @@
+package retry
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+)
+
+// Client retries requests of the underlying doer according to the configured policy (or the policy of the
+// request context, see WithPolicy)
+type Client struct {
+	underlying api.HttpRequestDoer
+	policy     Policy
+}
+
+var _ api.HttpRequestDoer = &Client{}
+
+// NewClient returns new retrying client which wraps given doer
+func NewClient(underlying api.HttpRequestDoer, policy Policy) (*Client, error) {
+	if err := assert.NotNil(underlying); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		underlying: underlying,
+		policy:     policy,
+	}, nil
+}
+
+// Do implements HttpRequestDoer and executes HTTP request, retrying it on network errors and transient HTTP
+// status codes
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := assert.NotNil(req); err != nil {
+		return nil, err
+	}
+
+	policy := c.policy
+	if override, ok := PolicyFromContext(req.Context()); ok {
+		policy = override
+	}
+
+	maxAttempts := policy.MaxAttempts
+	if !isRetryable(req, policy) {
+		maxAttempts = 1
+	}
+
+	attempt := 1
+	rsp, err := c.underlying.Do(req)
+
+	for ; attempt < maxAttempts; attempt++ {
+		wait, retry := backoff(policy, attempt, rsp, err)
+		if !retry || !sleep(req, wait) {
+			break
+		}
+
+		nextReq, reqErr := rewind(req)
+		if reqErr != nil {
+			break
+		}
+
+		if rsp != nil {
+			drain(rsp.Body)
+		}
+
+		rsp, err = c.underlying.Do(nextReq)
+	}
+
+	if err != nil {
+		return nil, &Error{Attempts: attempt, err: err}
+	}
+
+	if rsp.Header == nil {
+		rsp.Header = http.Header{}
+	}
+
+	rsp.Header.Set(AttemptsHeader, strconv.Itoa(attempt))
+
+	return rsp, nil
+}
+
+// sleep waits for given duration, it returns false if the request context is done before or would be done
+// afterward
+func sleep(req *http.Request, wait time.Duration) bool {
+	ctx := req.Context()
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// isRetryable returns true if given request may be retried at all (idempotent or opt-in, body can be replayed)
+func isRetryable(req *http.Request, policy Policy) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return policy.RetryNonIdempotent
+	}
+}
+
+// backoff returns the wait before the next attempt and true if the result of the given (1-based) attempt should
+// be retried
+func backoff(policy Policy, attempt int, rsp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		return jitter(policy, attempt), true
+	}
+
+	switch rsp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+
+	if wait, ok := retryAfter(rsp); ok {
+		return wait, wait <= policy.MaxBackoff
+	}
+
+	return jitter(policy, attempt), true
+}
+
+// jitter returns a random wait between zero and the exponential backoff of given (1-based) attempt
+func jitter(policy Policy, attempt int) time.Duration {
+	ceiling := policy.InitialBackoff
+	for i := 1; i < attempt && ceiling < policy.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+
+	if ceiling > policy.MaxBackoff {
+		ceiling = policy.MaxBackoff
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	//nolint:gosec
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// retryAfter parses the Retry-After header (seconds or HTTP date) of given response
+func retryAfter(rsp *http.Response) (time.Duration, bool) {
+	value := rsp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	wait := time.Until(date)
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait, true
+}
+
+// rewind returns a copy of given request with a fresh body so that it can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody == nil {
+		return next, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	next.Body = body
+
+	return next, nil
+}
+
+// drain reads and closes given response body so that the connection can be reused
+func drain(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, body)
+	_ = body.Close()
+}
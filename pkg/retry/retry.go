@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AttemptsHeader is set by the SDK (not the Backend API) on responses passed through Client and contains the
+// number of attempts it took to get the response
+const AttemptsHeader = "X-Corbado-SDK-Attempts"
+
+// Policy configures automatic retries of Backend API requests which failed with a network error or a transient
+// HTTP status code (429, 500, 502, 503 and 504)
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts (including the first one), values below two disable retries
+	MaxAttempts int
+
+	// InitialBackoff is the upper bound of the randomized (full jitter) wait before the first retry, it doubles
+	// with every further retry
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between two attempts. If the Backend API asks for a longer wait (Retry-After header)
+	// the request is not retried anymore.
+	MaxBackoff time.Duration
+
+	// RetryNonIdempotent retries non-idempotent requests (POST and PATCH) as well, they might be executed more
+	// than once then
+	RetryNonIdempotent bool
+}
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+)
+
+// DefaultPolicy returns the default policy which retries idempotent requests (GET, HEAD, OPTIONS, PUT and DELETE)
+// up to two times
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+	}
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a context which overrides the configured policy for all requests made with it, e.g. to
+// retry a single POST request
+func WithPolicy(ctx context.Context, policy Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, policy)
+}
+
+// WithoutRetries returns a context which disables retries for all requests made with it
+func WithoutRetries(ctx context.Context) context.Context {
+	return WithPolicy(ctx, Policy{MaxAttempts: 1})
+}
+
+// PolicyFromContext returns the policy set by WithPolicy (or WithoutRetries), if any
+func PolicyFromContext(ctx context.Context) (Policy, bool) {
+	policy, ok := ctx.Value(policyKey{}).(Policy)
+
+	return policy, ok
+}
+
+// Error is returned if a request failed with a network error (no response has been received), it contains the
+// number of attempts made
+type Error struct {
+	Attempts int
+	err      error
+}
+
+// Error implements error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (attempts: %d)", e.err.Error(), e.Attempts)
+}
+
+// Unwrap returns the error of the last attempt
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// AsError casts given error into an Error, if possible
+func AsError(err error) *Error {
+	var retryErr *Error
+	if !errors.As(err, &retryErr) {
+		return nil
+	}
+
+	return retryErr
+}
+
+// Attempts returns the number of attempts it took to get given response (nilable), zero if unknown
+func Attempts(rsp *http.Response) int {
+	if rsp == nil {
+		return 0
+	}
+
+	attempts, err := strconv.Atoi(rsp.Header.Get(AttemptsHeader))
+	if err != nil {
+		return 0
+	}
+
+	return attempts
+}
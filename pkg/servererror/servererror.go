@@ -2,9 +2,11 @@ package servererror
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/corbado/corbado-go/v2/pkg/generated/common"
+	"github.com/corbado/corbado-go/v2/pkg/retry"
 )
 
 type ServerError struct {
@@ -21,6 +23,9 @@ type ServerError struct {
 	Message        string             `json:"message"`
 	RequestData    common.RequestData `json:"requestData"`
 	Runtime        float32            `json:"runtime"`
+
+	// Attempts is the number of attempts made by the SDK (see retry.Policy), zero if unknown
+	Attempts int `json:"attempts,omitempty"`
 }
 
 // New wraps an error response into a ServerError
@@ -43,6 +48,19 @@ func New(cause *common.ErrorRsp) *ServerError {
 	}
 }
 
+// NewFromResponse wraps an error response into a ServerError including the number of attempts it took to get
+// given HTTP response (nilable)
+func NewFromResponse(cause *common.ErrorRsp, rsp *http.Response) *ServerError {
+	serverErr := New(cause)
+	if serverErr == nil {
+		return nil
+	}
+
+	serverErr.Attempts = retry.Attempts(rsp)
+
+	return serverErr
+}
+
 // Error implements error interface
 func (s *ServerError) Error() string {
 	msg := fmt.Sprintf("[%d %s]", s.HTTPStatusCode, s.Message)
@@ -58,6 +76,10 @@ func (s *ServerError) Error() string {
 
 	msg = fmt.Sprintf("%s (requestID=%s, type=%s)", msg, s.RequestData.RequestID, s.Type)
 
+	if s.Attempts > 1 {
+		msg = fmt.Sprintf("%s (attempts=%d)", msg, s.Attempts)
+	}
+
 	return msg
 }
 
@@ -0,0 +1,122 @@
+package stepup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+)
+
+// ErrorCode is the error code of ReauthenticationRequiredError, see RFC 9470 (OAuth 2.0 Step Up Authentication
+// Challenge Protocol)
+const ErrorCode = "insufficient_user_authentication"
+
+// ReauthenticationRequiredError is returned if the user authenticated longer ago than allowed, the frontend
+// should ask the user to authenticate again
+type ReauthenticationRequiredError struct {
+	// MaxAge is the maximum allowed authentication age
+	MaxAge time.Duration
+
+	// AuthenticatedAt is the time the user authenticated (zero if unknown)
+	AuthenticatedAt time.Time
+}
+
+// Error implements error interface
+func (e *ReauthenticationRequiredError) Error() string {
+	return fmt.Sprintf("%s: reauthentication required (max age: %s)", ErrorCode, e.MaxAge)
+}
+
+// AsReauthenticationRequiredError casts given error into a ReauthenticationRequiredError, if possible
+func AsReauthenticationRequiredError(err error) *ReauthenticationRequiredError {
+	var reauthErr *ReauthenticationRequiredError
+	if !errors.As(err, &reauthErr) {
+		return nil
+	}
+
+	return reauthErr
+}
+
+// SessionValidator validates session tokens, it is implemented by SDK.Sessions()
+type SessionValidator interface {
+	ValidateTokenWithContext(ctx context.Context, sessionToken string) (*entities.User, error)
+}
+
+// AuthenticatedAt returns the time the user authenticated, this is the auth_time claim if the session token
+// contains one and the issued at time (iat) otherwise
+func AuthenticatedAt(user *entities.User) time.Time {
+	if user.Claims != nil && user.Claims.AuthTime != nil {
+		return user.Claims.AuthTime.Time
+	}
+
+	return user.IssuedAt
+}
+
+// Require returns a ReauthenticationRequiredError if the given user authenticated longer than maxAge before now
+func Require(user *entities.User, maxAge time.Duration, now time.Time) error {
+	if err := assert.NotNil(user); err != nil {
+		return err
+	}
+
+	authenticatedAt := AuthenticatedAt(user)
+	if authenticatedAt.IsZero() || now.Sub(authenticatedAt) > maxAge {
+		return errors.WithStack(&ReauthenticationRequiredError{
+			MaxAge:          maxAge,
+			AuthenticatedAt: authenticatedAt,
+		})
+	}
+
+	return nil
+}
+
+// Validator validates session tokens and enforces a maximum authentication age
+type Validator struct {
+	sessions SessionValidator
+	clock    func() time.Time
+}
+
+// Option configures the validator
+type Option func(v *Validator)
+
+// WithClock sets the clock (defaults to time.Now)
+func WithClock(clock func() time.Time) Option {
+	return func(v *Validator) {
+		v.clock = clock
+	}
+}
+
+// NewValidator returns new validator
+func NewValidator(sessions SessionValidator, opts ...Option) (*Validator, error) {
+	if err := assert.NotNil(sessions); err != nil {
+		return nil, err
+	}
+
+	v := &Validator{
+		sessions: sessions,
+		clock:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v, nil
+}
+
+// ValidateToken validates the given session token and returns a ReauthenticationRequiredError if the user
+// authenticated longer than maxAge ago
+func (v *Validator) ValidateToken(ctx context.Context, sessionToken string, maxAge time.Duration) (*entities.User, error) {
+	user, err := v.sessions.ValidateTokenWithContext(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Require(user, maxAge, v.clock()); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
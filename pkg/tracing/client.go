@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/retry"
+)
+
+// Client creates a client span for every request and propagates the trace context to the Backend API
+type Client struct {
+	underlying api.HttpRequestDoer
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ api.HttpRequestDoer = &Client{}
+
+// NewClient returns new tracing client which wraps given doer
+func NewClient(underlying api.HttpRequestDoer, tracer trace.Tracer, propagator propagation.TextMapPropagator) (*Client, error) {
+	if err := assert.NotNil(underlying, tracer, propagator); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		underlying: underlying,
+		tracer:     tracer,
+		propagator: propagator,
+	}, nil
+}
+
+// Do implements HttpRequestDoer and executes HTTP request within a client span
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := assert.NotNil(req); err != nil {
+		return nil, err
+	}
+
+	operation, ok := OperationFromContext(req.Context())
+	if !ok {
+		operation = fmt.Sprintf("HTTP %s", req.Method)
+	}
+
+	ctx, span := c.tracer.Start(
+		req.Context(),
+		operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttributeOperation.String(operation),
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(spanURL(req.URL)),
+		),
+	)
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	rsp, err := c.underlying.Do(req)
+	if err != nil {
+		if retryErr := retry.AsError(err); retryErr != nil {
+			span.SetAttributes(AttributeAttempts.Int(retryErr.Attempts))
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rsp.StatusCode))
+
+	if attempts := retry.Attempts(rsp); attempts > 0 {
+		span.SetAttributes(AttributeAttempts.Int(attempts))
+	}
+
+	requestID, err := readRequestID(rsp)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err
+	}
+
+	if requestID != "" {
+		span.SetAttributes(AttributeRequestID.String(requestID))
+	}
+
+	if rsp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(rsp.StatusCode))
+	}
+
+	return rsp, nil
+}
+
+// spanURL returns given URL without query and fragment (e.g. the filter of IdentifierList contains emails)
+func spanURL(u *url.URL) string {
+	stripped := *u
+	stripped.RawQuery = ""
+	stripped.ForceQuery = false
+	stripped.Fragment = ""
+	stripped.RawFragment = ""
+	stripped.User = nil
+
+	return stripped.String()
+}
+
+// readRequestID returns the request ID of given JSON response (empty if there is none), the body can be read
+// again afterward
+func readRequestID(rsp *http.Response) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(rsp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" || rsp.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var envelope struct {
+		RequestData struct {
+			RequestID string `json:"requestID"`
+		} `json:"requestData"`
+	}
+
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return "", nil
+	}
+
+	return envelope.RequestData.RequestID, nil
+}
@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer used by the SDK
+const InstrumentationName = "github.com/corbado/corbado-go/v2"
+
+// Attributes set on spans of the SDK (in addition to the HTTP semantic conventions)
+const (
+	// AttributeOperation is the Backend API operation (e.g. "UserGet")
+	AttributeOperation = attribute.Key("corbado.operation")
+
+	// AttributeRequestID is the request ID of the Backend API response (see common.RequestData)
+	AttributeRequestID = attribute.Key("corbado.request_id")
+
+	// AttributeAttempts is the number of attempts made (see retry.Policy)
+	AttributeAttempts = attribute.Key("corbado.retry.attempts")
+
+	// AttributeProjectID is the project ID the session token has been validated for
+	AttributeProjectID = attribute.Key("corbado.project_id")
+
+	// AttributeValidationCode is the code of the validation error (see validationerror.Code)
+	AttributeValidationCode = attribute.Key("corbado.validation.code")
+)
+
+// NewTracer returns the tracer of the SDK from given tracer provider (nilable, defaults to the global one)
+func NewTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(InstrumentationName)
+}
+
+// NewPropagator returns given propagator (nilable), it defaults to the global one
+func NewPropagator(propagator propagation.TextMapPropagator) propagation.TextMapPropagator {
+	if propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+
+	return propagator
+}
+
+type operationKey struct{}
+
+// WithOperation returns a context which names the Backend API operation of the requests made with it
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the operation set by WithOperation, if any
+func OperationFromContext(ctx context.Context) (string, bool) {
+	operation, ok := ctx.Value(operationKey{}).(string)
+
+	return operation, ok
+}
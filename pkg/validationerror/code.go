@@ -1,5 +1,11 @@
 package validationerror
 
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
 type Code int
 
 const (
@@ -10,4 +16,63 @@ const (
 	CodeJWTBefore
 	CodeJWTExpired
 	CodeJWTIssuerEmpty
+	CodeTimeout
+	CodeCanceled
+	CodeJWTClaimsInvalid
+	CodeJWTTooOld
+	CodeJWTAlgorithmNotAllowed
+	CodeJWTUnknownKey
+	CodeJWTRevoked
+	CodeUserNotActive
+	CodeLongSessionNotActive
 )
+
+var codeNames = map[Code]string{
+	CodeJWTGeneral:             "jwt_general",
+	CodeJWTIssuerMismatch:      "jwt_issuer_mismatch",
+	CodeJWTInvalidData:         "jwt_invalid_data",
+	CodeJWTInvalidSignature:    "jwt_invalid_signature",
+	CodeJWTBefore:              "jwt_before",
+	CodeJWTExpired:             "jwt_expired",
+	CodeJWTIssuerEmpty:         "jwt_issuer_empty",
+	CodeTimeout:                "timeout",
+	CodeCanceled:               "canceled",
+	CodeJWTClaimsInvalid:       "jwt_claims_invalid",
+	CodeJWTTooOld:              "jwt_too_old",
+	CodeJWTAlgorithmNotAllowed: "jwt_algorithm_not_allowed",
+	CodeJWTUnknownKey:          "jwt_unknown_key",
+	CodeJWTRevoked:             "jwt_revoked",
+	CodeUserNotActive:          "user_not_active",
+	CodeLongSessionNotActive:   "long_session_not_active",
+}
+
+// String implements fmt.Stringer
+func (c Code) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("code(%d)", int(c))
+}
+
+// MarshalText implements encoding.TextMarshaler (codes are marshalled to their names in JSON)
+func (c Code) MarshalText() ([]byte, error) {
+	if _, ok := codeNames[c]; !ok {
+		return nil, errors.Errorf("unknown validation error code %d", int(c))
+	}
+
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (c *Code) UnmarshalText(text []byte) error {
+	for code, name := range codeNames {
+		if name == string(text) {
+			*c = code
+
+			return nil
+		}
+	}
+
+	return errors.Errorf("unknown validation error code '%s'", string(text))
+}
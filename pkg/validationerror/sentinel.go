@@ -0,0 +1,42 @@
+package validationerror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// codeError is the type of the sentinel errors, a ValidationError matches the sentinel of its code in errors.Is
+type codeError Code
+
+// Error implements error interface
+func (c codeError) Error() string {
+	return "validation error: " + Code(c).String()
+}
+
+// Sentinel errors per code to be used with errors.Is, e.g. errors.Is(err, validationerror.ErrJWTExpired)
+var (
+	ErrJWTGeneral             error = codeError(CodeJWTGeneral)
+	ErrJWTIssuerMismatch      error = codeError(CodeJWTIssuerMismatch)
+	ErrJWTInvalidData         error = codeError(CodeJWTInvalidData)
+	ErrJWTInvalidSignature    error = codeError(CodeJWTInvalidSignature)
+	ErrJWTBefore              error = codeError(CodeJWTBefore)
+	ErrJWTExpired             error = codeError(CodeJWTExpired)
+	ErrJWTIssuerEmpty         error = codeError(CodeJWTIssuerEmpty)
+	ErrTimeout                error = codeError(CodeTimeout)
+	ErrCanceled               error = codeError(CodeCanceled)
+	ErrJWTClaimsInvalid       error = codeError(CodeJWTClaimsInvalid)
+	ErrJWTTooOld              error = codeError(CodeJWTTooOld)
+	ErrJWTAlgorithmNotAllowed error = codeError(CodeJWTAlgorithmNotAllowed)
+	ErrJWTUnknownKey          error = codeError(CodeJWTUnknownKey)
+	ErrJWTRevoked             error = codeError(CodeJWTRevoked)
+	ErrUserNotActive          error = codeError(CodeUserNotActive)
+	ErrLongSessionNotActive   error = codeError(CodeLongSessionNotActive)
+)
+
+// Fingerprint returns a redacted fingerprint of given session token (first 16 hex characters of the SHA-256
+// hash) which can be logged to correlate errors without exposing the token
+func Fingerprint(sessionToken string) string {
+	hash := sha256.Sum256([]byte(sessionToken))
+
+	return "sha256:" + hex.EncodeToString(hash[:8])
+}
@@ -1,21 +1,87 @@
 package validationerror
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
+// ValidationError is returned if a session token is not valid. It never contains the raw session token unless
+// explicitly enabled (see Token).
 type ValidationError struct {
-	Message string
-	Code    Code
+	// Message is the full error message, use Reason for the cause only
+	Message string `json:"message"`
+	Code    Code   `json:"code"`
+
+	// Reason describes why the validation failed
+	Reason string `json:"reason"`
+
+	// Issuer, KeyID and ExpiresAt are taken from the session token (if it could be decoded), they are not
+	// necessarily verified and must only be used for diagnostics
+	Issuer    string    `json:"issuer,omitempty"`
+	KeyID     string    `json:"kid,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+
+	// TokenFingerprint identifies the session token without exposing it (see Fingerprint)
+	TokenFingerprint string `json:"tokenFingerprint,omitempty"`
+
+	// Token is the raw session token, it is only set if explicitly enabled and never marshalled
+	Token string `json:"-"`
+
+	err error
 }
 
 // New returns new validation error
 func New(message string, code Code) *ValidationError {
 	return &ValidationError{
 		Message: message,
+		Reason:  message,
 		Code:    code,
 	}
 }
 
+// Wrap returns new validation error with given underlying error (see Unwrap)
+func Wrap(err error, message string, code Code) *ValidationError {
+	validationErr := New(message, code)
+	validationErr.err = err
+
+	return validationErr
+}
+
 // Error implements error interface
 func (v *ValidationError) Error() string {
-	return fmt.Sprintf("%s (code: %d)", v.Message, v.Code)
+	return fmt.Sprintf("%s (code: %s)", v.Message, v.Code)
+}
+
+// Unwrap returns the underlying error (e.g. the error of the JWT library or context.DeadlineExceeded), if any
+func (v *ValidationError) Unwrap() error {
+	return v.err
+}
+
+// Is makes errors.Is match the sentinel of the validation error code (e.g. ErrJWTExpired)
+func (v *ValidationError) Is(target error) bool {
+	sentinel, ok := target.(codeError)
+	if !ok {
+		return false
+	}
+
+	return Code(sentinel) == v.Code
+}
+
+// MarshalJSON implements json.Marshaler (omits zero ExpiresAt)
+func (v *ValidationError) MarshalJSON() ([]byte, error) {
+	type alias ValidationError
+
+	var expiresAt *time.Time
+	if !v.ExpiresAt.IsZero() {
+		expiresAt = &v.ExpiresAt
+	}
+
+	return json.Marshal(&struct {
+		*alias
+		ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	}{
+		alias:     (*alias)(v),
+		ExpiresAt: expiresAt,
+	})
 }
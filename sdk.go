@@ -1,15 +1,18 @@
 package corbado
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/pkg/errors"
 
 	"github.com/corbado/corbado-go/v2/internal/assert"
+	"github.com/corbado/corbado-go/v2/internal/lifecycle"
 	"github.com/corbado/corbado-go/v2/internal/services/identifier"
 	"github.com/corbado/corbado-go/v2/internal/services/session"
 	"github.com/corbado/corbado-go/v2/internal/services/user"
+	"github.com/corbado/corbado-go/v2/pkg/circuitbreaker"
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
 	"github.com/corbado/corbado-go/v2/pkg/servererror"
 	"github.com/corbado/corbado-go/v2/pkg/validationerror"
@@ -17,15 +20,20 @@ import (
 
 const Version = "2.2.2"
 
+// ErrClosed is returned by all SDK calls made after Close
+var ErrClosed = lifecycle.ErrClosed
+
 type SDK interface {
 	Sessions() session.Session
 	Users() user.User
 	Identifiers() identifier.Identifier
+	Close(ctx context.Context) error
 }
 
 type Impl struct {
 	client     *api.ClientWithResponses
 	HTTPClient *http.Client
+	tracker    *lifecycle.Tracker
 
 	sessions    session.Session
 	users       user.User
@@ -44,23 +52,17 @@ func NewSDK(config *Config) (*Impl, error) {
 		return nil, err
 	}
 
-	client, err := newClient(config)
+	tracker := lifecycle.NewTracker()
+	httpClient := config.httpClient()
+
+	client, err := newClient(config, httpClient, tracker)
 	if err != nil {
 		return nil, err
 	}
 
 	// instantiate all APIs eagerly because it's cheap to do so and we don't have to deal with thread safety this way
 
-	sessionConfig := &session.Config{
-		ProjectID:            config.ProjectID,
-		JWTIssuer:            config.FrontendAPI,
-		JwksURI:              fmt.Sprintf("%s/.well-known/jwks", config.FrontendAPI),
-		JWKSRefreshInterval:  config.JWKSRefreshInterval,
-		JWKSRefreshRateLimit: config.JWKSRefreshRateLimit,
-		JWKSRefreshTimeout:   config.JWKSRefreshTimeout,
-	}
-
-	sessions, err := session.New(client, sessionConfig)
+	sessions, err := session.New(client, newSessionConfig(config, httpClient))
 	if err != nil {
 		return nil, err
 	}
@@ -75,20 +77,31 @@ func NewSDK(config *Config) (*Impl, error) {
 		return nil, err
 	}
 
-	httpClient := config.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{}
-	}
-
 	return &Impl{
 		client:      client,
 		sessions:    sessions,
 		users:       users,
 		HTTPClient:  httpClient,
+		tracker:     tracker,
 		identifiers: identifiers,
 	}, nil
 }
 
+// Close shuts down the SDK: It stops the background JWKS refresh and waits for in-flight Backend API calls and
+// session token validations to finish (or the given context to be done). All calls afterward fail with ErrClosed.
+func (i *Impl) Close(ctx context.Context) error {
+	if err := assert.NotNil(ctx); err != nil {
+		return err
+	}
+
+	sessionsErr := i.sessions.Close(ctx)
+	if err := i.tracker.Close(ctx); err != nil {
+		return err
+	}
+
+	return sessionsErr
+}
+
 // Sessions returns sessions client
 func (i *Impl) Sessions() session.Session {
 	return i.sessions
@@ -104,6 +117,41 @@ func (i *Impl) Identifiers() identifier.Identifier {
 	return i.identifiers
 }
 
+// newSessionConfig returns the session config for given config, the JWKS is fetched with given HTTP client
+func newSessionConfig(config *Config, httpClient *http.Client) *session.Config {
+	return &session.Config{
+		ProjectID:             config.ProjectID,
+		JWTIssuer:             config.FrontendAPI,
+		JwksURI:               fmt.Sprintf("%s/.well-known/jwks", config.FrontendAPI),
+		JWKSRefreshInterval:   config.JWKSRefreshInterval,
+		JWKSRefreshRateLimit:  config.JWKSRefreshRateLimit,
+		JWKSRefreshTimeout:    config.JWKSRefreshTimeout,
+		JWKSEagerLoad:         config.JWKSEagerLoad,
+		JWKSFailFast:          config.JWKSFailFast,
+		Leeway:                config.JWTLeeway,
+		MaxTokenAge:           config.JWTMaxTokenAge,
+		Clock:                 config.Clock,
+		Algorithms:            config.JWTAlgorithms,
+		PinnedKeyIDs:          config.JWKSPinnedKeyIDs,
+		PinnedKeyThumbprints:  config.JWKSPinnedKeyThumbprints,
+		Issuers:               config.JWTIssuers,
+		IssuerPatterns:        config.JWTIssuerPatterns,
+		DisableLegacyIssuers:  config.DisableLegacyJWTIssuers,
+		JWKS:                  config.JWKS,
+		JWKSFile:              config.JWKSFile,
+		JWKSFS:                config.JWKSFS,
+		JWKSFilePollInterval:  config.JWKSFilePollInterval,
+		JWKSCacheFile:         config.JWKSCacheFile,
+		JWKSCacheMaxStaleness: config.JWKSCacheMaxStaleness,
+		TokenCacheSize:        config.JWTCacheSize,
+		Denylist:              config.JWTDenylist,
+		StrictCacheTTL:        config.StrictValidationCacheTTL,
+		IncludeTokenInErrors:  config.JWTIncludeTokenInErrors,
+		HTTPClient:            httpClient,
+		TracerProvider:        config.TracerProvider,
+	}
+}
+
 // IsServerError checks if given error is a ServerError
 func IsServerError(err error) bool {
 	var serverErr *servererror.ServerError
@@ -122,6 +170,25 @@ func AsServerError(err error) *servererror.ServerError {
 	return serverErr
 }
 
+// IsCircuitOpenError checks if given error is a circuitbreaker.OpenError (Backend API has not been called because
+// the circuit of the endpoint group is open)
+func IsCircuitOpenError(err error) bool {
+	var openErr *circuitbreaker.OpenError
+
+	return errors.As(err, &openErr)
+}
+
+// AsCircuitOpenError casts given error into a circuitbreaker.OpenError, if possible
+func AsCircuitOpenError(err error) *circuitbreaker.OpenError {
+	var openErr *circuitbreaker.OpenError
+	ok := errors.As(err, &openErr)
+	if !ok {
+		return nil
+	}
+
+	return openErr
+}
+
 // IsValidationError checks if given error is a ValidationError
 func IsValidationError(err error) bool {
 	var validationErr *validationerror.ValidationError
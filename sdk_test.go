@@ -0,0 +1,67 @@
+package corbado
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSDK(t *testing.T, handler http.Handler) *Impl {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config, err := NewConfig("pro-12345678", "corbado1_secret", server.URL, server.URL)
+	require.NoError(t, err)
+
+	sdk, err := NewSDK(config)
+	require.NoError(t, err)
+
+	return sdk
+}
+
+func TestImpl_Close(t *testing.T) {
+	var requests int64
+	release := make(chan struct{})
+
+	sdk := newTestSDK(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		<-release
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"userID":"usr-1","status":"active"}`))
+	}))
+
+	// Start a request which is in-flight during close
+	done := make(chan error)
+	go func() {
+		_, err := sdk.Users().Get(context.Background(), "usr-1")
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&requests) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// Close must wait for the in-flight request
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sdk.Close(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, <-done)
+	require.NoError(t, sdk.Close(context.Background()))
+
+	// All calls after close fail
+	_, err := sdk.Users().Get(context.Background(), "usr-1")
+	assert.ErrorIs(t, err, ErrClosed)
+
+	_, err = sdk.Sessions().ValidateToken("token")
+	assert.ErrorIs(t, err, ErrClosed)
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+}
@@ -0,0 +1,30 @@
+package corbado
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/corbado/corbado-go/v2/pkg/logger"
+)
+
+// NewKeySetStatusHandler returns a http.Handler which renders the JWKS status (see Sessions().KeySetStatus())
+// as JSON, e.g. for admin or health endpoints. It responds with HTTP status code 503 (Service Unavailable) as
+// long as the JWKS has not been loaded.
+func NewKeySetStatusHandler(sdk SDK) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		status := sdk.Sessions().KeySetStatus()
+
+		statusCode := http.StatusOK
+		if !status.Ready {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(statusCode)
+
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			logger.Error("Error writing JWKS status: %s", err.Error())
+		}
+	})
+}
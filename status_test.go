@@ -0,0 +1,43 @@
+package corbado
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+)
+
+func TestNewKeySetStatusHandler(t *testing.T) {
+	sdk := newTestSDK(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	}))
+
+	handler := NewKeySetStatusHandler(sdk)
+
+	// Not loaded yet
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jwks-status", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	// Loading the JWKS succeeds (but token is invalid)
+	_, err := sdk.Sessions().ValidateToken("invalid")
+	require.Error(t, err)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jwks-status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var status entities.KeySetStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.True(t, status.Ready)
+	assert.Equal(t, "http", status.Source)
+	assert.Empty(t, status.Keys)
+	assert.False(t, status.LastRefresh.IsZero())
+}
@@ -0,0 +1,262 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/circuitbreaker"
+)
+
+// statusDoer responds with the configured HTTP status code (or a network error for zero) and counts all calls
+type statusDoer struct {
+	mu     sync.Mutex
+	status int
+	calls  int
+}
+
+func (s *statusDoer) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+
+	if s.status == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return &http.Response{
+		StatusCode: s.status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func (s *statusDoer) respond(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+}
+
+func (s *statusDoer) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+func newTestClient(t *testing.T, doer *statusDoer, settings circuitbreaker.Settings) (*circuitbreaker.Client, *fakeClock, *[]string) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var changes []string
+
+	client, err := circuitbreaker.NewClient(doer, &circuitbreaker.Config{
+		Default: settings,
+		Clock:   clock.Now,
+		OnStateChange: func(group string, from circuitbreaker.State, to circuitbreaker.State) {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", group, from, to))
+		},
+	})
+	require.NoError(t, err)
+
+	return client, clock, &changes
+}
+
+func do(ctx context.Context, t *testing.T, client *circuitbreaker.Client, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost"+path, nil)
+	require.NoError(t, err)
+
+	rsp, err := client.Do(req)
+	if rsp != nil {
+		_ = rsp.Body.Close()
+	}
+
+	return err
+}
+
+func TestClient_Do_Open(t *testing.T) {
+	doer := &statusDoer{status: http.StatusServiceUnavailable}
+	client, clock, changes := newTestClient(t, doer, circuitbreaker.Settings{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, do(context.Background(), t, client, "/v2/users/usr-1"))
+	}
+
+	assert.Equal(t, circuitbreaker.StateOpen, client.State("users"))
+	assert.Equal(t, []string{"users: closed -> open"}, *changes)
+
+	// Fails fast without calling the Backend API
+	err := do(context.Background(), t, client, "/v2/users/usr-2")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, circuitbreaker.ErrOpen)
+
+	openErr := circuitbreaker.AsOpenError(err)
+	require.NotNil(t, openErr)
+	assert.Equal(t, "users", openErr.Group)
+	assert.Equal(t, clock.Now().Add(time.Minute), openErr.RetryAt)
+	assert.Equal(t, 3, doer.callCount())
+
+	// Other endpoint groups are not affected
+	doer.respond(http.StatusOK)
+	require.NoError(t, do(context.Background(), t, client, "/v2/identifiers"))
+	assert.Equal(t, circuitbreaker.StateClosed, client.State("identifiers"))
+}
+
+func TestClient_Do_HalfOpen(t *testing.T) {
+	doer := &statusDoer{status: 0}
+	client, clock, changes := newTestClient(t, doer, circuitbreaker.Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 2})
+
+	require.Error(t, do(context.Background(), t, client, "/v2/users"))
+	assert.Equal(t, circuitbreaker.StateOpen, client.State("users"))
+
+	// Failing probe opens the circuit again
+	clock.Advance(time.Minute)
+	assert.Equal(t, circuitbreaker.StateHalfOpen, client.State("users"))
+
+	err := do(context.Background(), t, client, "/v2/users")
+	require.Error(t, err)
+	assert.Nil(t, circuitbreaker.AsOpenError(err))
+	assert.Equal(t, circuitbreaker.StateOpen, client.State("users"))
+
+	// Circuit is closed after all probes succeeded
+	clock.Advance(time.Minute)
+	doer.respond(http.StatusOK)
+
+	require.NoError(t, do(context.Background(), t, client, "/v2/users"))
+	assert.Equal(t, circuitbreaker.StateHalfOpen, client.State("users"))
+
+	require.NoError(t, do(context.Background(), t, client, "/v2/users"))
+	assert.Equal(t, circuitbreaker.StateClosed, client.State("users"))
+
+	assert.Equal(t, []string{
+		"users: closed -> open",
+		"users: open -> half-open",
+		"users: half-open -> open",
+		"users: open -> half-open",
+		"users: half-open -> closed",
+	}, *changes)
+}
+
+func TestClient_Do_Outcomes(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		cancel bool
+		state  circuitbreaker.State
+	}{
+		{name: "Server error", status: http.StatusInternalServerError, state: circuitbreaker.StateOpen},
+		{name: "Network error", status: 0, state: circuitbreaker.StateOpen},
+		{name: "Client error", status: http.StatusNotFound, state: circuitbreaker.StateClosed},
+		{name: "Canceled by caller", status: 0, cancel: true, state: circuitbreaker.StateClosed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doer := &statusDoer{status: test.status}
+			client, _, _ := newTestClient(t, doer, circuitbreaker.Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if test.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			_ = do(ctx, t, client, "/v2/users")
+
+			assert.Equal(t, test.state, client.State("users"))
+		})
+	}
+}
+
+func TestClient_Do_GroupSettings(t *testing.T) {
+	doer := &statusDoer{status: http.StatusBadGateway}
+
+	client, err := circuitbreaker.NewClient(doer, &circuitbreaker.Config{
+		Default: circuitbreaker.Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+		Groups: map[string]circuitbreaker.Settings{
+			"identifiers": {FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, do(context.Background(), t, client, "/v2/identifiers"))
+	assert.Equal(t, circuitbreaker.StateClosed, client.State("identifiers"))
+
+	require.NoError(t, do(context.Background(), t, client, "/v2/identifiers"))
+	assert.Equal(t, circuitbreaker.StateOpen, client.State("identifiers"))
+
+	require.NoError(t, do(context.Background(), t, client, "/v2/users"))
+	assert.Equal(t, circuitbreaker.StateOpen, client.State("users"))
+}
+
+func TestNewClient_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *circuitbreaker.Config
+	}{
+		{
+			name:   "Empty default settings",
+			config: &circuitbreaker.Config{},
+		},
+		{
+			name: "Invalid group settings",
+			config: &circuitbreaker.Config{
+				Default: circuitbreaker.DefaultConfig().Default,
+				Groups: map[string]circuitbreaker.Settings{
+					"users": {FailureThreshold: 1, OpenTimeout: time.Minute},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := circuitbreaker.NewClient(&statusDoer{}, test.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		path  string
+		group string
+	}{
+		{path: "/v2/users", group: "users"},
+		{path: "/v2/users/usr-1/identifiers/ide-1", group: "users"},
+		{path: "/prefix/v2/identifiers", group: "identifiers"},
+		{path: "/health", group: "health"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.group, circuitbreaker.Group(test.path), test.path)
+	}
+}
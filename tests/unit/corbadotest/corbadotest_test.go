@@ -0,0 +1,80 @@
+package corbadotest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/corbadotest"
+	"github.com/corbado/corbado-go/v2/pkg/middleware"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+func TestServer_MintToken(t *testing.T) {
+	server := corbadotest.NewServer(t)
+	sdk := server.NewSDK()
+
+	user, err := sdk.Sessions().ValidateToken(server.MintToken())
+	require.NoError(t, err)
+	assert.Equal(t, corbadotest.DefaultUserID, user.UserID)
+	assert.Equal(t, corbadotest.DefaultProjectID, user.ProjectID)
+	assert.Equal(t, "test@example.com", user.LoginIdentifier)
+
+	user, err = sdk.Sessions().ValidateToken(server.MintToken(corbadotest.WithSubject("usr-1"), corbadotest.WithClaim("name", "Jane")))
+	require.NoError(t, err)
+	assert.Equal(t, "usr-1", user.UserID)
+	assert.Equal(t, "Jane", user.FullName)
+
+	_, err = sdk.Sessions().ValidateToken(server.MintToken(corbadotest.WithExpiry(time.Now().Add(-time.Minute))))
+	assert.ErrorIs(t, err, validationerror.ErrJWTExpired)
+
+	_, err = sdk.Sessions().ValidateToken(server.MintToken(corbadotest.WithIssuer("https://evil.com")))
+	assert.ErrorIs(t, err, validationerror.ErrJWTIssuerMismatch)
+}
+
+func TestServer_RotateKey(t *testing.T) {
+	server := corbadotest.NewServer(t, corbadotest.WithProjectID("pro-1"))
+	sdk := server.NewSDK()
+
+	oldToken := server.MintToken()
+	_, err := sdk.Sessions().ValidateToken(oldToken)
+	require.NoError(t, err)
+
+	server.RotateKey()
+
+	// New key is picked up (unknown key ID triggers a JWKS refresh on the first request)
+	_, err = sdk.Sessions().ValidateToken(server.MintToken())
+	require.NoError(t, err)
+
+	_, err = sdk.Sessions().ValidateToken(oldToken)
+	assert.ErrorIs(t, err, validationerror.ErrJWTUnknownKey)
+}
+
+func TestServer_Middleware(t *testing.T) {
+	server := corbadotest.NewServer(t, corbadotest.WithBackendAPIHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	handler := middleware.RequireSession(server.NewSDK())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := middleware.UserFromContext(r.Context())
+		_, _ = w.Write([]byte(user.UserID))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+server.MintToken())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, corbadotest.DefaultUserID, rec.Body.String())
+
+	// Backend API requests are passed to the handler
+	rsp, err := http.Get(server.URL + "/v2/users/usr-1")
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusTeapot, rsp.StatusCode)
+}
@@ -0,0 +1,107 @@
+package denylist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/denylist"
+)
+
+func TestMemory_IsRevoked(t *testing.T) {
+	now := time.Now()
+
+	store := denylist.NewMemory()
+	store.RevokeToken("jti-revoked", now.Add(time.Hour))
+	store.RevokeUser("usr-disabled")
+	store.RevokeUserTokensIssuedBefore("usr-logout", now)
+
+	tests := []struct {
+		name    string
+		token   denylist.Token
+		revoked bool
+	}{
+		{
+			name:    "Valid token",
+			token:   denylist.Token{ID: "jti-1", UserID: "usr-1", IssuedAt: now.Add(-time.Minute)},
+			revoked: false,
+		},
+		{
+			name:    "Revoked token ID",
+			token:   denylist.Token{ID: "jti-revoked", UserID: "usr-1", IssuedAt: now.Add(-time.Minute)},
+			revoked: true,
+		},
+		{
+			name:    "Revoked user",
+			token:   denylist.Token{ID: "jti-1", UserID: "usr-disabled", IssuedAt: now.Add(time.Minute)},
+			revoked: true,
+		},
+		{
+			name:    "Issued before logout",
+			token:   denylist.Token{ID: "jti-1", UserID: "usr-logout", IssuedAt: now.Add(-time.Minute)},
+			revoked: true,
+		},
+		{
+			name:    "Issued after logout",
+			token:   denylist.Token{ID: "jti-1", UserID: "usr-logout", IssuedAt: now.Add(time.Minute)},
+			revoked: false,
+		},
+		{
+			name:    "No issued at",
+			token:   denylist.Token{ID: "jti-1", UserID: "usr-logout"},
+			revoked: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := test.token
+			revoked, err := store.IsRevoked(context.Background(), &token)
+			require.NoError(t, err)
+			assert.Equal(t, test.revoked, revoked)
+		})
+	}
+}
+
+func TestMemory_Restore(t *testing.T) {
+	store := denylist.NewMemory()
+	token := &denylist.Token{UserID: "usr-1"}
+
+	store.RevokeUser("usr-1")
+	revoked, err := store.IsRevoked(context.Background(), token)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	store.RestoreUser("usr-1")
+	revoked, err = store.IsRevoked(context.Background(), token)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Earlier cutoffs do not overwrite later ones
+	now := time.Now()
+	store.RevokeUserTokensIssuedBefore("usr-1", now)
+	store.RevokeUserTokensIssuedBefore("usr-1", now.Add(-time.Hour))
+
+	revoked, err = store.IsRevoked(context.Background(), &denylist.Token{UserID: "usr-1", IssuedAt: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestMemory_ExpiredTokens(t *testing.T) {
+	store := denylist.NewMemory()
+	store.RevokeToken("jti-expired", time.Now().Add(-time.Minute))
+
+	// Revoking another token removes revocations of expired tokens
+	store.RevokeToken("jti-1", time.Now().Add(time.Minute))
+
+	revoked, err := store.IsRevoked(context.Background(), &denylist.Token{ID: "jti-expired"})
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = store.IsRevoked(context.Background(), &denylist.Token{ID: "jti-1"})
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
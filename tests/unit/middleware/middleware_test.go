@@ -0,0 +1,300 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/middleware"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+// newSDK mocks the Frontend API (JWKS endpoint) and creates a new SDK
+func newSDK(t *testing.T) (corbado.SDK, string) {
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	jwksData, err := os.ReadFile(filepath.Join(workingDir, "../testdata/jwks.json"))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(jwksData)
+	}))
+	t.Cleanup(server.Close)
+
+	config, err := corbado.NewConfig("pro-1", "corbado1_secret", server.URL, server.URL)
+	require.NoError(t, err)
+
+	sdk, err := corbado.NewSDK(config)
+	require.NoError(t, err)
+
+	return sdk, server.URL
+}
+
+func generateJWT(t *testing.T, issuer string, exp time.Time) string {
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	privateKeyFile, err := os.ReadFile(filepath.Join(workingDir, "../testdata/validPrivateKey.pem"))
+	require.NoError(t, err)
+
+	var privateKey *rsa.PrivateKey
+	privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(privateKeyFile)
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":  issuer,
+		"iat":  time.Now().Unix(),
+		"exp":  exp.Unix(),
+		"sub":  "usr-1234567890",
+		"name": "name",
+	})
+	token.Header["kid"] = "kid123"
+
+	tokenString, err := token.SignedString(privateKey)
+	require.NoError(t, err)
+
+	return tokenString
+}
+
+func userHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		_, _ = w.Write([]byte("anonymous"))
+
+		return
+	}
+
+	_, _ = w.Write([]byte(user.UserID))
+}
+
+// nolint:funlen
+func TestSessionMiddlewares(t *testing.T) {
+	sdk, issuer := newSDK(t)
+
+	validToken := generateJWT(t, issuer, time.Now().Add(time.Minute))
+	expiredToken := generateJWT(t, issuer, time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name               string
+		middleware         func(http.Handler) http.Handler
+		cookie             string
+		authorization      string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Required: no token",
+			middleware:         middleware.RequireSession(sdk),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Required: valid cookie",
+			middleware:         middleware.RequireSession(sdk),
+			cookie:             validToken,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "usr-1234567890",
+		},
+		{
+			name:               "Required: valid bearer token",
+			middleware:         middleware.RequireSession(sdk),
+			authorization:      "Bearer " + validToken,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "usr-1234567890",
+		},
+		{
+			name:               "Required: expired token",
+			middleware:         middleware.RequireSession(sdk),
+			cookie:             expiredToken,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Required: custom extractor",
+			middleware:         middleware.RequireSession(sdk, middleware.WithTokenExtractors(middleware.HeaderExtractor("X-Session"))),
+			cookie:             validToken,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Required: custom error responder",
+			middleware: middleware.RequireSession(sdk, middleware.WithErrorResponder(func(w http.ResponseWriter, r *http.Request, _ error) {
+				http.Redirect(w, r, "/login", http.StatusFound)
+			})),
+			expectedStatusCode: http.StatusFound,
+		},
+		{
+			name:               "Optional: no token",
+			middleware:         middleware.OptionalSession(sdk),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "anonymous",
+		},
+		{
+			name:               "Optional: expired token",
+			middleware:         middleware.OptionalSession(sdk),
+			authorization:      "Bearer " + expiredToken,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "anonymous",
+		},
+		{
+			name:               "Optional: valid token",
+			middleware:         middleware.OptionalSession(sdk),
+			authorization:      "Bearer " + validToken,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "usr-1234567890",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: middleware.DefaultCookieName, Value: test.cookie})
+			}
+
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			test.middleware(http.HandlerFunc(userHandler)).ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireStepUp(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return issuedAt.Add(10 * time.Minute) }
+
+	tests := []struct {
+		name               string
+		user               *entities.User
+		maxAge             time.Duration
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Recent authentication",
+			user:               &entities.User{UserID: "usr-1", IssuedAt: issuedAt},
+			maxAge:             15 * time.Minute,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "usr-1",
+		},
+		{
+			name:               "Reauthentication required",
+			user:               &entities.User{UserID: "usr-1", IssuedAt: issuedAt},
+			maxAge:             5 * time.Minute,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "{\"error\":\"insufficient_user_authentication\",\"max_age\":300}\n",
+		},
+		{
+			name:               "No user",
+			maxAge:             5 * time.Minute,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "Unauthorized\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := middleware.RequireStepUp(test.maxAge, middleware.WithClock(clock))(http.HandlerFunc(userHandler))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.user != nil {
+				req = req.WithContext(middleware.ContextWithUser(req.Context(), test.user))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Equal(t, test.expectedBody, rec.Body.String())
+
+			if test.user != nil && test.expectedStatusCode == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer error="insufficient_user_authentication", error_description="Reauthentication required", max_age=300`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestDefaultErrorResponder(t *testing.T) {
+	tests := []struct {
+		name               string
+		err                error
+		expectedStatusCode int
+	}{
+		{
+			name:               "No session token",
+			err:                middleware.ErrNoSessionToken,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Invalid session token",
+			err:                validationerror.New("expired", validationerror.CodeJWTExpired),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Timeout",
+			err:                validationerror.Wrap(context.DeadlineExceeded, "timeout", validationerror.CodeTimeout),
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:               "Canceled",
+			err:                validationerror.Wrap(context.Canceled, "canceled", validationerror.CodeCanceled),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Unexpected error",
+			err:                errors.New("unexpected"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			middleware.DefaultErrorResponder(rec, httptest.NewRequest(http.MethodGet, "/", nil), test.err)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+		})
+	}
+}
+
+func TestOptionalSession_Canceled(t *testing.T) {
+	sdk, issuer := newSDK(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+generateJWT(t, issuer, time.Now().Add(time.Minute)))
+
+	var responderErr error
+
+	rec := httptest.NewRecorder()
+	middleware.OptionalSession(sdk, middleware.WithErrorResponder(func(w http.ResponseWriter, _ *http.Request, err error) {
+		responderErr = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}))(http.HandlerFunc(userHandler)).ServeHTTP(rec, req)
+
+	// Request is not treated as anonymous
+	assert.ErrorIs(t, responderErr, validationerror.ErrCanceled)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
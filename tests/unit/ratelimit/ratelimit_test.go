@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/ratelimit"
+)
+
+// concurrencyDoer responds after given delay and records the maximum number of concurrent requests
+type concurrencyDoer struct {
+	delay    time.Duration
+	current  int64
+	max      int64
+	requests int64
+}
+
+func (c *concurrencyDoer) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&c.requests, 1)
+
+	current := atomic.AddInt64(&c.current, 1)
+	defer atomic.AddInt64(&c.current, -1)
+
+	for {
+		observed := atomic.LoadInt64(&c.max)
+		if current <= observed || atomic.CompareAndSwapInt64(&c.max, observed, current) {
+			break
+		}
+	}
+
+	time.Sleep(c.delay)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func newRequest(ctx context.Context, t *testing.T) *http.Request {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/v2/users", nil)
+	require.NoError(t, err)
+
+	return req
+}
+
+func TestClient_Do_RequestsPerSecond(t *testing.T) {
+	doer := &concurrencyDoer{}
+
+	client, err := ratelimit.NewClient(doer, ratelimit.Config{RequestsPerSecond: 20, Burst: 2})
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	// Two requests (burst) are sent right away, the remaining three are delayed by 50ms each
+	for i := 0; i < 5; i++ {
+		_, err := client.Do(newRequest(context.Background(), t))
+		require.NoError(t, err)
+	}
+
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 140*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
+
+func TestClient_Do_MaxInFlight(t *testing.T) {
+	doer := &concurrencyDoer{delay: 10 * time.Millisecond}
+
+	client, err := ratelimit.NewClient(doer, ratelimit.Config{MaxInFlight: 2})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := client.Do(newRequest(context.Background(), t))
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(10), atomic.LoadInt64(&doer.requests))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&doer.max))
+}
+
+func TestClient_Do_ContextCanceled(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ratelimit.Config
+	}{
+		{
+			name:   "Waiting for rate limit",
+			config: ratelimit.Config{RequestsPerSecond: 0.1},
+		},
+		{
+			name:   "Waiting for in-flight slot",
+			config: ratelimit.Config{MaxInFlight: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doer := &concurrencyDoer{delay: 200 * time.Millisecond}
+
+			client, err := ratelimit.NewClient(doer, test.config)
+			require.NoError(t, err)
+
+			// Uses up the token or in-flight slot
+			go func() {
+				_, _ = client.Do(newRequest(context.Background(), t))
+			}()
+
+			time.Sleep(20 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			_, err = client.Do(newRequest(ctx, t))
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Equal(t, int64(1), atomic.LoadInt64(&doer.requests))
+		})
+	}
+}
+
+func TestNewClient_InvalidConfig(t *testing.T) {
+	tests := []ratelimit.Config{
+		{RequestsPerSecond: -1},
+		{RequestsPerSecond: 1, Burst: -1},
+		{MaxInFlight: -1},
+	}
+
+	for _, config := range tests {
+		_, err := ratelimit.NewClient(&concurrencyDoer{}, config)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,260 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/retry"
+)
+
+// scriptedDoer returns the given status codes (or a network error for zero) one after another
+type scriptedDoer struct {
+	mu       sync.Mutex
+	statuses []int
+	header   http.Header
+	bodies   []string
+}
+
+func (s *scriptedDoer) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		s.bodies = append(s.bodies, string(body))
+	}
+
+	status := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+
+	if status == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	header := http.Header{}
+	for key, values := range s.header {
+		header[key] = values
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+func (s *scriptedDoer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.bodies)
+}
+
+func newPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+}
+
+func newRequest(ctx context.Context, t *testing.T, method string) *http.Request {
+	req, err := http.NewRequestWithContext(ctx, method, "http://localhost/v2/users", bytes.NewReader([]byte(`{"fullName":"Jane"}`)))
+	require.NoError(t, err)
+
+	return req
+}
+
+func TestClient_Do(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		ctx              func(ctx context.Context) context.Context
+		statuses         []int
+		header           http.Header
+		expectedStatus   int
+		expectedAttempts int
+	}{
+		{
+			name:             "Success without retry",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Retry GET until success",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Retry DELETE after network error",
+			method:           http.MethodDelete,
+			statuses:         []int{0, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Give up after max attempts",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusTooManyRequests},
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "No retry on client error",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusBadRequest, http.StatusOK},
+			expectedStatus:   http.StatusBadRequest,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "No retry of POST by default",
+			method:           http.MethodPost,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "Retry of POST with policy from context",
+			method: http.MethodPost,
+			ctx: func(ctx context.Context) context.Context {
+				policy := newPolicy()
+				policy.RetryNonIdempotent = true
+
+				return retry.WithPolicy(ctx, policy)
+			},
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "No retry with retries disabled by context",
+			method:           http.MethodGet,
+			ctx:              retry.WithoutRetries,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Retry after short Retry-After",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusTooManyRequests, http.StatusOK},
+			header:           http.Header{"Retry-After": []string{"0"}},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "No retry after Retry-After exceeding max backoff",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusTooManyRequests, http.StatusOK},
+			header:           http.Header{"Retry-After": []string{"120"}},
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doer := &scriptedDoer{statuses: test.statuses, header: test.header}
+
+			client, err := retry.NewClient(doer, newPolicy())
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			if test.ctx != nil {
+				ctx = test.ctx(ctx)
+			}
+
+			rsp, err := client.Do(newRequest(ctx, t, test.method))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatus, rsp.StatusCode)
+			assert.Equal(t, test.expectedAttempts, retry.Attempts(rsp))
+			assert.Equal(t, test.expectedAttempts, doer.calls())
+
+			// Request body is sent with every attempt
+			for _, body := range doer.bodies {
+				assert.Equal(t, `{"fullName":"Jane"}`, body)
+			}
+		})
+	}
+}
+
+func TestClient_Do_NetworkError(t *testing.T) {
+	doer := &scriptedDoer{statuses: []int{0}}
+
+	client, err := retry.NewClient(doer, newPolicy())
+	require.NoError(t, err)
+
+	rsp, err := client.Do(newRequest(context.Background(), t, http.MethodGet))
+	require.Error(t, err)
+	assert.Nil(t, rsp)
+
+	retryErr := retry.AsError(err)
+	require.NotNil(t, retryErr)
+	assert.Equal(t, 3, retryErr.Attempts)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Contains(t, err.Error(), "attempts: 3")
+}
+
+func TestClient_Do_RetryAfter(t *testing.T) {
+	doer := &scriptedDoer{
+		statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+		header:   http.Header{"Retry-After": []string{"1"}},
+	}
+
+	policy := newPolicy()
+	policy.MaxBackoff = 2 * time.Second
+
+	client, err := retry.NewClient(doer, policy)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	rsp, err := client.Do(newRequest(context.Background(), t, http.MethodGet))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestClient_Do_ContextDeadline(t *testing.T) {
+	doer := &scriptedDoer{
+		statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+		header:   http.Header{"Retry-After": []string{"30"}},
+	}
+
+	policy := newPolicy()
+	policy.MaxBackoff = time.Hour
+
+	client, err := retry.NewClient(doer, policy)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Retry-After does not fit into the deadline, so the last response is returned right away
+	rsp, err := client.Do(newRequest(ctx, t, http.MethodGet))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	assert.Equal(t, 1, retry.Attempts(rsp))
+}
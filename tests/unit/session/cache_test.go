@@ -0,0 +1,205 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+)
+
+func newFailingJWKSServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestValidateToken_JWKSCache(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+	cacheFile := filepath.Join(t.TempDir(), "jwks-cache.json")
+
+	// Populate cache
+	server, _ := newJWKSServer(t, 0)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:             "pro-1",
+		JwksURI:               server.URL,
+		JWKSCacheFile:         cacheFile,
+		JWKSCacheMaxStaleness: time.Hour,
+	})
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.FileExists(t, cacheFile)
+
+	// JWKS endpoint is down, cache is used
+	sessionSvc = newSessionWithConfig(t, &session.Config{
+		ProjectID:             "pro-1",
+		JwksURI:               newFailingJWKSServer(t).URL,
+		JWKSCacheFile:         cacheFile,
+		JWKSCacheMaxStaleness: time.Hour,
+	})
+
+	user, err := sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, "usr-1234567890", user.UserID)
+	assert.True(t, sessionSvc.Ready())
+
+	// JWKS endpoint is down, cache is too old
+	data, err := os.ReadFile(cacheFile)
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(data, &entry))
+	entry["fetchedAt"] = time.Now().Add(-2 * time.Hour)
+
+	data, err = json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cacheFile, data, 0o600))
+
+	sessionSvc = newSessionWithConfig(t, &session.Config{
+		ProjectID:             "pro-1",
+		JwksURI:               newFailingJWKSServer(t).URL,
+		JWKSCacheFile:         cacheFile,
+		JWKSCacheMaxStaleness: time.Hour,
+	})
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.Error(t, err)
+	assert.False(t, sessionSvc.Ready())
+
+	// No cache at all
+	sessionSvc = newSessionWithConfig(t, &session.Config{
+		ProjectID:             "pro-1",
+		JwksURI:               newFailingJWKSServer(t).URL,
+		JWKSCacheFile:         filepath.Join(t.TempDir(), "missing.json"),
+		JWKSCacheMaxStaleness: time.Hour,
+	})
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.Error(t, err)
+	assert.False(t, sessionSvc.Ready())
+}
+
+func TestValidateToken_JWKSCacheETag(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+	cacheFile := filepath.Join(t.TempDir(), "jwks-cache.json")
+	jwksData := readJWKS(t)
+
+	var notModified int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt64(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := w.Write(jwksData); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	config := func() *session.Config {
+		return &session.Config{
+			ProjectID:             "pro-1",
+			JwksURI:               server.URL,
+			JWKSCacheFile:         cacheFile,
+			JWKSCacheMaxStaleness: time.Hour,
+		}
+	}
+
+	_, err = newSessionWithConfig(t, config()).ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&notModified))
+
+	data, err := os.ReadFile(cacheFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"etag":"\"v1\""`)
+
+	// Restart, the cached ETag is used for a conditional request
+	sessionSvc := newSessionWithConfig(t, config())
+	user, err := sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, "usr-1234567890", user.UserID)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&notModified))
+}
+
+func TestValidateToken_JWKSCacheRecovery(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+	cacheFile := filepath.Join(t.TempDir(), "jwks-cache.json")
+	jwksData := readJWKS(t)
+
+	// Populate cache
+	server, _ := newJWKSServer(t, 0)
+	_, err = newSessionWithConfig(t, &session.Config{
+		ProjectID:             "pro-1",
+		JwksURI:               server.URL,
+		JWKSCacheFile:         cacheFile,
+		JWKSCacheMaxStaleness: time.Hour,
+	}).ValidateToken(sessionToken)
+	require.NoError(t, err)
+
+	// JWKS endpoint fails the first two requests (initial fetch and first retry)
+	var requests int64
+	recovering := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(jwksData)
+	}))
+	t.Cleanup(recovering.Close)
+
+	start := time.Now()
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:             "pro-1",
+		JwksURI:               recovering.URL,
+		JWKSCacheFile:         cacheFile,
+		JWKSCacheMaxStaleness: time.Hour,
+		JWKSRefreshInterval:   time.Hour,
+		JWKSRefreshRateLimit:  20 * time.Millisecond,
+	})
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.True(t, sessionSvc.KeySetStatus().LastRefresh.Before(start), "cached JWKS")
+
+	// Fetch is retried well before the refresh interval
+	require.Eventually(t, func() bool {
+		return sessionSvc.KeySetStatus().LastRefresh.After(start)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requests))
+
+	// Back to the refresh interval
+	assert.Eventually(t, func() bool {
+		return sessionSvc.KeySetStatus().NextRefresh.After(time.Now().Add(time.Minute))
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+type customClaims struct {
+	entities.Claims
+	Tenant string   `json:"tenant"`
+	Roles  []string `json:"roles"`
+}
+
+func TestValidateTokenInto(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	issuer := "https://pro-1.frontendapi.cloud.corbado.io"
+	sessionSvc := newSession(t, issuer)
+
+	sessionToken := generateJWTWithClaims(jwt.MapClaims{
+		"iss":    issuer,
+		"iat":    time.Now().Unix(),
+		"exp":    time.Now().Add(100 * time.Second).Unix(),
+		"sub":    "usr-1234567890",
+		"name":   "name",
+		"tenant": "acme",
+		"roles":  []string{"admin"},
+	}, validPrivateKey, jwt.SigningMethodRS256)
+
+	t.Run("Success", func(t *testing.T) {
+		claims, err := corbado.ValidateTokenInto[customClaims](context.Background(), sessionSvc, sessionToken)
+		require.NoError(t, err)
+
+		assert.Equal(t, "usr-1234567890", claims.Subject)
+		assert.Equal(t, "name", claims.Name)
+		assert.Equal(t, "acme", claims.Tenant)
+		assert.Equal(t, []string{"admin"}, claims.Roles)
+	})
+
+	t.Run("Success with validator", func(t *testing.T) {
+		claims, err := corbado.ValidateTokenInto[customClaims](context.Background(), sessionSvc, sessionToken, func(claims *customClaims) error {
+			if claims.Tenant != "acme" {
+				return errors.New("wrong tenant")
+			}
+
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "acme", claims.Tenant)
+	})
+
+	t.Run("Failed validator", func(t *testing.T) {
+		claims, err := corbado.ValidateTokenInto[customClaims](context.Background(), sessionSvc, sessionToken, func(claims *customClaims) error {
+			return errors.New("missing role")
+		})
+		assert.Nil(t, claims)
+
+		var validationErr *validationerror.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, validationerror.CodeJWTClaimsInvalid, validationErr.Code)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expiredToken := generateJWT(issuer, time.Now().Add(-100*time.Second).Unix(), time.Now().Add(-100*time.Second).Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+		claims, err := corbado.ValidateTokenInto[customClaims](context.Background(), sessionSvc, expiredToken)
+		assert.Nil(t, claims)
+
+		var validationErr *validationerror.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, validationerror.CodeJWTExpired, validationErr.Code)
+	})
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/denylist"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+type failingStore struct{}
+
+func (f *failingStore) IsRevoked(_ context.Context, _ *denylist.Token) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestValidateToken_Denylist(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	store := denylist.NewMemory()
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:      "pro-1",
+		JWKS:           readJWKS(t),
+		TokenCacheSize: 10,
+		Denylist:       store,
+	})
+
+	now := time.Now()
+	sessionToken := generateJWTWithClaims(jwt.MapClaims{
+		"iss": "https://pro-1.frontendapi.cloud.corbado.io",
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+		"sub": "usr-1",
+		"jti": "jti-1",
+	}, validPrivateKey, jwt.SigningMethodRS256)
+
+	// Valid (and cached)
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+
+	// Revocations apply to cached tokens as well
+	store.RevokeToken("jti-1", now.Add(time.Minute))
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	var validationErr *validationerror.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, validationerror.CodeJWTRevoked, validationErr.Code)
+
+	// Store errors fail closed
+	sessionSvc = newSessionWithConfig(t, &session.Config{
+		ProjectID: "pro-1",
+		JWKS:      readJWKS(t),
+		Denylist:  &failingStore{},
+	})
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.ErrorContains(t, err, "store unavailable")
+	assert.False(t, errors.As(err, &validationErr))
+}
@@ -3,30 +3,32 @@ package session
 import (
 	"context"
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/MicahParks/keyfunc"
 	"github.com/stretchr/testify/require"
 
-	"github.com/corbado/corbado-go/v2/pkg/logger"
 	"github.com/corbado/corbado-go/v2/pkg/validationerror"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/corbado/corbado-go/v2/internal/lifecycle"
 	"github.com/corbado/corbado-go/v2/internal/services/session"
 	"github.com/corbado/corbado-go/v2/pkg/generated/api"
 )
 
 func generateJWT(iss string, exp, nbf int64, privateKey *rsa.PrivateKey, method jwt.SigningMethod) string {
-	token := jwt.NewWithClaims(method, jwt.MapClaims{
+	return generateJWTWithClaims(jwt.MapClaims{
 		"iss":          iss,
 		"iat":          time.Now().Unix(),
 		"exp":          exp,
@@ -36,8 +38,11 @@ func generateJWT(iss string, exp, nbf int64, privateKey *rsa.PrivateKey, method
 		"email":        "email",
 		"phone_number": "phoneNumber",
 		"orig":         "orig",
-	})
+	}, privateKey, method)
+}
 
+func generateJWTWithClaims(claims jwt.MapClaims, privateKey *rsa.PrivateKey, method jwt.SigningMethod) string {
+	token := jwt.NewWithClaims(method, claims)
 	token.Header["kid"] = "kid123"
 
 	var key any
@@ -69,20 +74,25 @@ func generatePrivateKey(filename string) (*rsa.PrivateKey, error) {
 	return jwt.ParseRSAPrivateKeyFromPEM(privateKeyFile)
 }
 
-// newSession mocks the JWKS endpoint and creates a new session service
-func newSession(issuer string) (*session.Impl, error) {
+// newJWKSServer mocks the JWKS endpoint, given delay is applied before every response. The returned counter
+// holds the number of requests.
+func newJWKSServer(t *testing.T, delay time.Duration) (*httptest.Server, *int64) {
 	workingDir, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
+	require.NoError(t, err)
 
 	jwksData, err := os.ReadFile(filepath.Join(workingDir, "../testdata/jwks.json"))
-	if err != nil {
-		return nil, err
-	}
+	require.NoError(t, err)
+
+	var requests int64
+	mockServer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
 
-	// Create an HTTP mock server for the JWKS endpoint
-	mockServer := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 
 		if _, err := w.Write(jwksData); err != nil {
@@ -91,56 +101,44 @@ func newSession(issuer string) (*session.Impl, error) {
 	})
 
 	server := httptest.NewServer(mockServer)
-	defer server.Close()
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
 
-	// Config
-	config := &session.Config{
+// newSession mocks the JWKS endpoint and creates a new session service
+func newSession(t *testing.T, issuer string) *session.Impl {
+	server, _ := newJWKSServer(t, 0)
+
+	return newSessionWithConfig(t, &session.Config{
 		ProjectID: "pro-1",
 		JwksURI:   server.URL,
 		JWTIssuer: issuer,
-	}
-
-	// Create a new JWKS instance using the mock JWKS server
-	options := keyfunc.Options{
-		RequestFactory: func(ctx context.Context, urlAddress string) (*http.Request, error) {
-			req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlAddress, nil)
-			if err != nil {
-				return nil, err
-			}
-
-			req.Header.Set("X-Corbado-ProjectID", config.ProjectID)
+	})
+}
 
-			return req, nil
-		},
-		ResponseExtractor: func(_ context.Context, resp *http.Response) (json.RawMessage, error) {
-			defer resp.Body.Close()
+// newSessionWithConfig creates a new session service, missing config values are filled with defaults
+func newSessionWithConfig(t *testing.T, config *session.Config) *session.Impl {
+	if config.JWTIssuer == "" {
+		config.JWTIssuer = "https://pro-1.frontendapi.cloud.corbado.io"
+	}
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
+	if config.JWKSRefreshInterval == 0 {
+		config.JWKSRefreshInterval = time.Hour
+	}
 
-			return body, nil
-		},
-		RefreshErrorHandler: func(err error) {
-			logger.Error("Error refreshing JWKS: %s", err.Error())
-		},
-		RefreshInterval:   config.JWKSRefreshInterval,
-		RefreshRateLimit:  config.JWKSRefreshRateLimit,
-		RefreshTimeout:    config.JWKSRefreshTimeout,
-		RefreshUnknownKID: true,
+	if config.JWKSRefreshRateLimit == 0 {
+		config.JWKSRefreshRateLimit = 5 * time.Minute
 	}
 
-	jwks, err := keyfunc.Get(server.URL, options)
-	if err != nil {
-		return nil, err
+	if config.JWKSRefreshTimeout == 0 {
+		config.JWKSRefreshTimeout = 10 * time.Second
 	}
 
-	return &session.Impl{
-		Client: &api.ClientWithResponses{},
-		Config: config,
-		Jwks:   jwks,
-	}, nil
+	sessionSvc, err := session.New(&api.ClientWithResponses{}, config)
+	require.NoError(t, err)
+
+	return sessionSvc
 }
 
 // nolint:funlen
@@ -181,7 +179,7 @@ func TestValidateToken(t *testing.T) {
 			name:                "JWT with alg none",
 			issuer:              "https://pro-1.frontendapi.cloud.corbado.io",
 			sessionToken:        generateJWT("https://auth.acme.com", time.Now().Add(100*time.Second).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodNone),
-			validationErrorCode: validationerror.CodeJWTGeneral,
+			validationErrorCode: validationerror.CodeJWTAlgorithmNotAllowed,
 			success:             false,
 		},
 		{
@@ -259,8 +257,7 @@ func TestValidateToken(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			sessionSvc, err := newSession(test.issuer)
-			require.NoError(t, err)
+			sessionSvc := newSession(t, test.issuer)
 
 			user, err := sessionSvc.ValidateToken(test.sessionToken)
 
@@ -268,6 +265,13 @@ func TestValidateToken(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, user)
 				assert.Equal(t, "usr-1234567890", user.UserID)
+				assert.Equal(t, "name", user.FullName)
+				assert.Equal(t, "email", user.Email)
+				assert.Equal(t, "phoneNumber", user.PhoneNumber)
+				assert.Equal(t, "orig", user.LoginIdentifier)
+				assert.Equal(t, test.sessionToken, user.SessionToken)
+				assert.False(t, user.IssuedAt.IsZero())
+				assert.Greater(t, user.RemainingLifetime(time.Now()), time.Duration(0))
 			} else {
 				assert.Error(t, err)
 				assert.Nil(t, user)
@@ -281,3 +285,504 @@ func TestValidateToken(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTokenWithContext_Timeout(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	server, _ := newJWKSServer(t, 5*time.Second)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID: "pro-1",
+		JwksURI:   server.URL,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(100*time.Second).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+	user, err := sessionSvc.ValidateTokenWithContext(ctx, sessionToken)
+	assert.Nil(t, user)
+
+	var validationErr *validationerror.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, validationerror.CodeTimeout, validationErr.Code)
+}
+
+func TestValidateToken_ConcurrentFirstCalls(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	server, requests := newJWKSServer(t, 10*time.Millisecond)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID: "pro-1",
+		JwksURI:   server.URL,
+	})
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(100*time.Second).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			user, err := sessionSvc.ValidateToken(sessionToken)
+			assert.NoError(t, err)
+			assert.NotNil(t, user)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.True(t, sessionSvc.Ready())
+	assert.Equal(t, int64(1), atomic.LoadInt64(requests))
+}
+
+func TestValidateToken_ConcurrentUnknownKeyIDs(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	server, requests := newJWKSServer(t, 20*time.Millisecond)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:            "pro-1",
+		JwksURI:              server.URL,
+		JWKSRefreshRateLimit: 500 * time.Millisecond,
+	})
+
+	// Validating (even an invalid token) loads the JWKS
+	_, err = sessionSvc.ValidateToken("invalid")
+	require.Error(t, err)
+	require.Equal(t, int64(1), atomic.LoadInt64(requests))
+
+	// Rate limit has passed, so the first unknown key ID triggers a refresh
+	time.Sleep(510 * time.Millisecond)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": "https://pro-1.frontendapi.cloud.corbado.io"})
+	token.Header["kid"] = "unknown"
+
+	sessionToken, err := token.SignedString(validPrivateKey)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := sessionSvc.ValidateToken(sessionToken)
+			assert.Error(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	// All concurrent callers share one refresh
+	assert.Equal(t, int64(2), atomic.LoadInt64(requests))
+}
+
+func TestNew_JWKSEagerLoad(t *testing.T) {
+	server, requests := newJWKSServer(t, 0)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:     "pro-1",
+		JwksURI:       server.URL,
+		JWKSEagerLoad: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, sessionSvc.WaitReady(ctx))
+	assert.True(t, sessionSvc.Ready())
+	assert.Equal(t, int64(1), atomic.LoadInt64(requests))
+}
+
+func TestNew_JWKSFailFast(t *testing.T) {
+	server, _ := newJWKSServer(t, 0)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:    "pro-1",
+		JwksURI:      server.URL,
+		JWKSFailFast: true,
+	})
+	assert.True(t, sessionSvc.Ready())
+
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	sessionSvc, err := session.New(&api.ClientWithResponses{}, &session.Config{
+		ProjectID:            "pro-1",
+		JWTIssuer:            "https://pro-1.frontendapi.cloud.corbado.io",
+		JwksURI:              failingServer.URL,
+		JWKSRefreshInterval:  time.Hour,
+		JWKSRefreshRateLimit: time.Minute,
+		JWKSRefreshTimeout:   time.Second,
+		JWKSFailFast:         true,
+	})
+	assert.Nil(t, sessionSvc)
+	assert.ErrorContains(t, err, "Loading JWKS failed")
+}
+
+func TestClose(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	sessionSvc := newSession(t, "https://pro-1.frontendapi.cloud.corbado.io")
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(100*time.Second).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	user, err := sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.NotNil(t, user)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, sessionSvc.Close(ctx))
+	require.NoError(t, sessionSvc.Close(ctx))
+
+	user, err = sessionSvc.ValidateToken(sessionToken)
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, lifecycle.ErrClosed)
+}
+
+// nolint:funlen
+func TestValidateToken_TimeClaims(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	issuer := "https://pro-1.frontendapi.cloud.corbado.io"
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newToken := func(iat, nbf, exp time.Time) string {
+		return generateJWTWithClaims(jwt.MapClaims{
+			"iss": issuer,
+			"iat": iat.Unix(),
+			"nbf": nbf.Unix(),
+			"exp": exp.Unix(),
+			"sub": "usr-1234567890",
+		}, validPrivateKey, jwt.SigningMethodRS256)
+	}
+
+	tests := []struct {
+		name                string
+		leeway              time.Duration
+		maxTokenAge         time.Duration
+		sessionToken        string
+		validationErrorCode validationerror.Code
+		success             bool
+	}{
+		{
+			name:         "Valid",
+			sessionToken: newToken(now.Add(-time.Minute), now.Add(-time.Minute), now.Add(time.Minute)),
+			success:      true,
+		},
+		{
+			name:                "Expired without leeway",
+			sessionToken:        newToken(now.Add(-time.Minute), now.Add(-time.Minute), now.Add(-5*time.Second)),
+			validationErrorCode: validationerror.CodeJWTExpired,
+		},
+		{
+			name:         "Expired within leeway",
+			leeway:       10 * time.Second,
+			sessionToken: newToken(now.Add(-time.Minute), now.Add(-time.Minute), now.Add(-5*time.Second)),
+			success:      true,
+		},
+		{
+			name:                "Not before without leeway",
+			sessionToken:        newToken(now, now.Add(5*time.Second), now.Add(time.Minute)),
+			validationErrorCode: validationerror.CodeJWTBefore,
+		},
+		{
+			name:         "Not before within leeway",
+			leeway:       10 * time.Second,
+			sessionToken: newToken(now.Add(5*time.Second), now.Add(5*time.Second), now.Add(time.Minute)),
+			success:      true,
+		},
+		{
+			name:                "Issued in future",
+			sessionToken:        newToken(now.Add(5*time.Second), now, now.Add(time.Minute)),
+			validationErrorCode: validationerror.CodeJWTBefore,
+		},
+		{
+			name:                "Too old",
+			maxTokenAge:         time.Hour,
+			sessionToken:        newToken(now.Add(-2*time.Hour), now.Add(-2*time.Hour), now.Add(time.Minute)),
+			validationErrorCode: validationerror.CodeJWTTooOld,
+		},
+		{
+			name:         "Not too old",
+			maxTokenAge:  time.Hour,
+			sessionToken: newToken(now.Add(-30*time.Minute), now.Add(-30*time.Minute), now.Add(time.Minute)),
+			success:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newJWKSServer(t, 0)
+			sessionSvc := newSessionWithConfig(t, &session.Config{
+				ProjectID:   "pro-1",
+				JwksURI:     server.URL,
+				Leeway:      test.leeway,
+				MaxTokenAge: test.maxTokenAge,
+				Clock:       func() time.Time { return now },
+			})
+
+			user, err := sessionSvc.ValidateToken(test.sessionToken)
+			if test.success {
+				require.NoError(t, err)
+				assert.Equal(t, "usr-1234567890", user.UserID)
+
+				return
+			}
+
+			assert.Nil(t, user)
+
+			var validationErr *validationerror.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, test.validationErrorCode, validationErr.Code)
+		})
+	}
+}
+
+// nolint:funlen
+func TestValidateToken_AlgorithmsAndPinning(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	issuer := "https://pro-1.frontendapi.cloud.corbado.io"
+	claims := jwt.MapClaims{
+		"iss": issuer,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"sub": "usr-1234567890",
+	}
+
+	// Thumbprint (RFC 7638) of the key in testdata/jwks.json
+	jwksThumbprint := func() string {
+		workingDir, err := os.Getwd()
+		require.NoError(t, err)
+
+		jwksData, err := os.ReadFile(filepath.Join(workingDir, "../testdata/jwks.json"))
+		require.NoError(t, err)
+
+		var jwks struct {
+			Keys []struct {
+				E string `json:"e"`
+				N string `json:"n"`
+			} `json:"keys"`
+		}
+		require.NoError(t, json.Unmarshal(jwksData, &jwks))
+
+		hash := sha256.Sum256([]byte(`{"e":"` + jwks.Keys[0].E + `","kty":"RSA","n":"` + jwks.Keys[0].N + `"}`))
+
+		return base64.RawURLEncoding.EncodeToString(hash[:])
+	}()
+
+	unknownKIDToken := func() string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "unknown"
+
+		tokenString, err := token.SignedString(validPrivateKey)
+		require.NoError(t, err)
+
+		return tokenString
+	}()
+
+	tests := []struct {
+		name                string
+		config              session.Config
+		sessionToken        string
+		validationErrorCode validationerror.Code
+		success             bool
+	}{
+		{
+			name:         "RS256 allowed by default",
+			sessionToken: generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256),
+			success:      true,
+		},
+		{
+			name:                "RS512 not allowed by default",
+			sessionToken:        generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS512),
+			validationErrorCode: validationerror.CodeJWTAlgorithmNotAllowed,
+		},
+		{
+			name:                "RS256 not in allow-list",
+			config:              session.Config{Algorithms: []string{"ES256"}},
+			sessionToken:        generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256),
+			validationErrorCode: validationerror.CodeJWTAlgorithmNotAllowed,
+		},
+		{
+			name:                "Unknown key ID",
+			sessionToken:        unknownKIDToken,
+			validationErrorCode: validationerror.CodeJWTUnknownKey,
+		},
+		{
+			name:         "Pinned key ID",
+			config:       session.Config{PinnedKeyIDs: []string{"kid123"}},
+			sessionToken: generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256),
+			success:      true,
+		},
+		{
+			name:                "Key ID not pinned",
+			config:              session.Config{PinnedKeyIDs: []string{"kid456"}},
+			sessionToken:        generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256),
+			validationErrorCode: validationerror.CodeJWTUnknownKey,
+		},
+		{
+			name:         "Pinned thumbprint",
+			config:       session.Config{PinnedKeyThumbprints: []string{jwksThumbprint}},
+			sessionToken: generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256),
+			success:      true,
+		},
+		{
+			name:                "Thumbprint not pinned",
+			config:              session.Config{PinnedKeyThumbprints: []string{"invalid"}},
+			sessionToken:        generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256),
+			validationErrorCode: validationerror.CodeJWTUnknownKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newJWKSServer(t, 0)
+
+			config := test.config
+			config.ProjectID = "pro-1"
+			config.JwksURI = server.URL
+			sessionSvc := newSessionWithConfig(t, &config)
+
+			user, err := sessionSvc.ValidateToken(test.sessionToken)
+			if test.success {
+				require.NoError(t, err)
+				assert.Equal(t, "usr-1234567890", user.UserID)
+
+				return
+			}
+
+			assert.Nil(t, user)
+
+			var validationErr *validationerror.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, test.validationErrorCode, validationErr.Code)
+		})
+	}
+}
+
+// nolint:funlen
+func TestValidateToken_Issuers(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		config    session.Config
+		jwtIssuer string
+		success   bool
+	}{
+		{
+			name:      "Legacy issuer",
+			jwtIssuer: "https://pro-1.frontendapi.corbado.io",
+			success:   true,
+		},
+		{
+			name:      "Legacy issuer disabled",
+			config:    session.Config{DisableLegacyIssuers: true},
+			jwtIssuer: "https://pro-1.frontendapi.corbado.io",
+		},
+		{
+			name:      "Configured issuer with legacy issuers disabled",
+			config:    session.Config{JWTIssuer: "https://auth.acme.com", DisableLegacyIssuers: true},
+			jwtIssuer: "https://auth.acme.com",
+			success:   true,
+		},
+		{
+			name:      "Additional issuer",
+			config:    session.Config{JWTIssuer: "https://auth.acme.com", Issuers: []string{"https://auth.brand.com"}},
+			jwtIssuer: "https://auth.brand.com",
+			success:   true,
+		},
+		{
+			name:      "Issuer pattern",
+			config:    session.Config{JWTIssuer: "https://auth.acme.com", IssuerPatterns: []string{"auth.*.com"}},
+			jwtIssuer: "https://auth.brand.com",
+			success:   true,
+		},
+		{
+			name:      "Issuer pattern does not match path",
+			config:    session.Config{JWTIssuer: "https://auth.acme.com", IssuerPatterns: []string{"auth.*.com"}},
+			jwtIssuer: "https://auth.brand.com/path",
+		},
+		{
+			name:      "Issuer pattern does not match HTTP",
+			config:    session.Config{JWTIssuer: "https://auth.acme.com", IssuerPatterns: []string{"auth.*.com"}},
+			jwtIssuer: "http://auth.brand.com",
+		},
+		{
+			name:      "Issuer pattern does not match",
+			config:    session.Config{JWTIssuer: "https://auth.acme.com", IssuerPatterns: []string{"*.acme.com"}},
+			jwtIssuer: "https://auth.brand.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newJWKSServer(t, 0)
+
+			config := test.config
+			config.ProjectID = "pro-1"
+			config.JwksURI = server.URL
+			sessionSvc := newSessionWithConfig(t, &config)
+
+			sessionToken := generateJWT(test.jwtIssuer, time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+			user, err := sessionSvc.ValidateToken(sessionToken)
+			if test.success {
+				require.NoError(t, err)
+				assert.Equal(t, "usr-1234567890", user.UserID)
+
+				return
+			}
+
+			assert.Nil(t, user)
+
+			var validationErr *validationerror.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, validationerror.CodeJWTIssuerMismatch, validationErr.Code)
+		})
+	}
+}
+
+func TestValidateToken_ValidationErrorDetails(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", expiresAt.Unix(), time.Now().Add(-time.Hour).Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	sessionSvc := newSessionWithConfig(t, &session.Config{ProjectID: "pro-1", JWKS: readJWKS(t)})
+	_, err = sessionSvc.ValidateToken(sessionToken)
+
+	var validationErr *validationerror.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ErrorIs(t, err, validationerror.ErrJWTExpired)
+	assert.Equal(t, "https://pro-1.frontendapi.cloud.corbado.io", validationErr.Issuer)
+	assert.Equal(t, "kid123", validationErr.KeyID)
+	assert.True(t, expiresAt.Equal(validationErr.ExpiresAt))
+	assert.Equal(t, validationerror.Fingerprint(sessionToken), validationErr.TokenFingerprint)
+	assert.Empty(t, validationErr.Token)
+	assert.NotContains(t, err.Error(), sessionToken)
+
+	// Underlying JWT library error
+	_, err = sessionSvc.ValidateToken(sessionToken[:len(sessionToken)-4])
+	var libraryErr *jwt.ValidationError
+	assert.ErrorAs(t, err, &libraryErr)
+	assert.ErrorIs(t, err, validationerror.ErrJWTInvalidSignature)
+
+	// Raw token only if enabled explicitly
+	sessionSvc = newSessionWithConfig(t, &session.Config{ProjectID: "pro-1", JWKS: readJWKS(t), IncludeTokenInErrors: true})
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, sessionToken, validationErr.Token)
+}
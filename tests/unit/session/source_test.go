@@ -0,0 +1,107 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+)
+
+func readJWKS(t *testing.T) []byte {
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	jwksData, err := os.ReadFile(filepath.Join(workingDir, "../testdata/jwks.json"))
+	require.NoError(t, err)
+
+	return jwksData
+}
+
+func TestValidateToken_StaticJWKS(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	jwksData := readJWKS(t)
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	tests := []struct {
+		name   string
+		config session.Config
+	}{
+		{
+			name:   "Bytes",
+			config: session.Config{JWKS: jwksData},
+		},
+		{
+			name:   "File system",
+			config: session.Config{JWKSFS: fstest.MapFS{"keys/jwks.json": {Data: jwksData}}, JWKSFile: "keys/jwks.json"},
+		},
+		{
+			name:   "File",
+			config: session.Config{JWKSFile: "../testdata/jwks.json"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.ProjectID = "pro-1"
+			sessionSvc := newSessionWithConfig(t, &config)
+
+			// Loaded without network access right away
+			assert.True(t, sessionSvc.Ready())
+
+			user, err := sessionSvc.ValidateToken(sessionToken)
+			require.NoError(t, err)
+			assert.Equal(t, "usr-1234567890", user.UserID)
+		})
+	}
+}
+
+func TestValidateToken_JWKSFileReload(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys":[]}`), 0o600))
+
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:            "pro-1",
+		JWKSFile:             jwksFile,
+		JWKSFilePollInterval: 10 * time.Millisecond,
+	})
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(jwksFile, readJWKS(t), 0o600))
+
+	require.Eventually(t, func() bool {
+		_, err := sessionSvc.ValidateToken(sessionToken)
+
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNew_InvalidJWKSFile(t *testing.T) {
+	sessionSvc, err := session.New(&api.ClientWithResponses{}, &session.Config{
+		ProjectID:            "pro-1",
+		JWTIssuer:            "https://pro-1.frontendapi.cloud.corbado.io",
+		JWKSFile:             filepath.Join(t.TempDir(), "missing.json"),
+		JWKSRefreshInterval:  time.Hour,
+		JWKSRefreshRateLimit: time.Minute,
+		JWKSRefreshTimeout:   time.Second,
+	})
+	assert.Nil(t, sessionSvc)
+	assert.ErrorContains(t, err, "Loading JWKS failed")
+}
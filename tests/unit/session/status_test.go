@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+)
+
+func TestKeySetStatus(t *testing.T) {
+	server, _ := newJWKSServer(t, 0)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID: "pro-1",
+		JwksURI:   server.URL,
+	})
+
+	status := sessionSvc.KeySetStatus()
+	assert.False(t, status.Ready)
+	assert.Equal(t, "http", status.Source)
+	assert.Empty(t, status.Keys)
+	assert.True(t, status.LastRefresh.IsZero())
+
+	// Validating (even an invalid token) loads the JWKS
+	_, err := sessionSvc.ValidateToken("invalid")
+	require.Error(t, err)
+
+	status = sessionSvc.KeySetStatus()
+	assert.True(t, status.Ready)
+	require.Len(t, status.Keys, 1)
+	assert.Equal(t, "kid123", status.Keys[0].KeyID)
+	assert.Equal(t, "RSA", status.Keys[0].KeyType)
+	assert.Equal(t, "RS256", status.Keys[0].Algorithm)
+	assert.NotEmpty(t, status.Keys[0].Thumbprint)
+	assert.WithinDuration(t, time.Now(), status.LastRefresh, time.Minute)
+	assert.Empty(t, status.LastError)
+
+	require.Eventually(t, func() bool {
+		return !sessionSvc.KeySetStatus().NextRefresh.IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), sessionSvc.KeySetStatus().NextRefresh, time.Minute)
+
+	require.NoError(t, sessionSvc.Close(context.Background()))
+	assert.True(t, sessionSvc.KeySetStatus().NextRefresh.IsZero())
+}
+
+func TestKeySetStatus_LastError(t *testing.T) {
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID: "pro-1",
+		JwksURI:   newFailingJWKSServer(t).URL,
+	})
+
+	_, err := sessionSvc.ValidateToken("token")
+	require.Error(t, err)
+
+	status := sessionSvc.KeySetStatus()
+	assert.False(t, status.Ready)
+	assert.Contains(t, status.LastError, "500")
+	assert.WithinDuration(t, time.Now(), status.LastErrorAt, time.Minute)
+}
+
+func TestKeySetStatus_Static(t *testing.T) {
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID: "pro-1",
+		JWKS:      readJWKS(t),
+	})
+
+	status := sessionSvc.KeySetStatus()
+	assert.True(t, status.Ready)
+	assert.Equal(t, "static", status.Source)
+	assert.Len(t, status.Keys, 1)
+	assert.True(t, status.NextRefresh.IsZero())
+}
@@ -0,0 +1,254 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/generated/api"
+	"github.com/corbado/corbado-go/v2/pkg/servererror"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+type backendAPIMock struct {
+	mu                sync.Mutex
+	userStatus        string
+	longSessionStatus string
+	failing           bool
+	requests          int64
+}
+
+func (b *backendAPIMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&b.requests, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if b.failing {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"httpStatusCode":500,"message":"internal error","requestData":{"requestID":"req-1","link":""},"runtime":0.1}`))
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	switch r.URL.Path {
+	case "/users/usr-1":
+		_, _ = fmt.Fprintf(w, `{"userID":"usr-1","status":"%s"}`, b.userStatus)
+
+	case "/users/usr-1/longSessions/lse-1":
+		_, _ = fmt.Fprintf(w, `{"longSessionID":"lse-1","userID":"usr-1","identifierValue":"","expires":"","status":"%s"}`, b.longSessionStatus)
+
+	default:
+		panic("unexpected path " + r.URL.Path)
+	}
+}
+
+func (b *backendAPIMock) set(userStatus string, longSessionStatus string, failing bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.userStatus = userStatus
+	b.longSessionStatus = longSessionStatus
+	b.failing = failing
+}
+
+func newStrictSession(t *testing.T, backendAPI *backendAPIMock, cacheTTL time.Duration) *session.Impl {
+	server := httptest.NewServer(backendAPI)
+	t.Cleanup(server.Close)
+
+	client, err := api.NewClientWithResponses(server.URL)
+	require.NoError(t, err)
+
+	sessionSvc, err := session.New(client, &session.Config{
+		ProjectID:            "pro-1",
+		JWTIssuer:            "https://pro-1.frontendapi.cloud.corbado.io",
+		JWKS:                 readJWKS(t),
+		JWKSRefreshInterval:  time.Hour,
+		JWKSRefreshRateLimit: time.Minute,
+		JWKSRefreshTimeout:   time.Second,
+		StrictCacheTTL:       cacheTTL,
+	})
+	require.NoError(t, err)
+
+	return sessionSvc
+}
+
+// nolint:funlen
+func TestValidateTokenStrict(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	newToken := func(sid string) string {
+		claims := jwt.MapClaims{
+			"iss": "https://pro-1.frontendapi.cloud.corbado.io",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Minute).Unix(),
+			"sub": "usr-1",
+		}
+
+		if sid != "" {
+			claims["sid"] = sid
+		}
+
+		return generateJWTWithClaims(claims, validPrivateKey, jwt.SigningMethodRS256)
+	}
+
+	tests := []struct {
+		name                string
+		sid                 string
+		userStatus          string
+		longSessionStatus   string
+		failing             bool
+		validationErrorCode validationerror.Code
+		serverError         bool
+		requests            int64
+	}{
+		{
+			name:                "Active user without session ID",
+			userStatus:          "active",
+			longSessionStatus:   "active",
+			validationErrorCode: validationerror.CodeJWTInvalidData,
+			requests:            0,
+		},
+		{
+			name:              "Active user and long session",
+			sid:               "lse-1",
+			userStatus:        "active",
+			longSessionStatus: "active",
+			requests:          2,
+		},
+		{
+			name:                "Disabled user",
+			sid:                 "lse-1",
+			userStatus:          "disabled",
+			longSessionStatus:   "active",
+			validationErrorCode: validationerror.CodeUserNotActive,
+			requests:            1,
+		},
+		{
+			name:                "Logged out long session",
+			sid:                 "lse-1",
+			userStatus:          "active",
+			longSessionStatus:   "logged_out",
+			validationErrorCode: validationerror.CodeLongSessionNotActive,
+			requests:            2,
+		},
+		{
+			name:        "Backend API error",
+			sid:         "lse-1",
+			failing:     true,
+			serverError: true,
+			requests:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backendAPI := &backendAPIMock{}
+			backendAPI.set(test.userStatus, test.longSessionStatus, test.failing)
+
+			sessionSvc := newStrictSession(t, backendAPI, 0)
+			user, err := sessionSvc.ValidateTokenStrict(context.Background(), newToken(test.sid))
+
+			switch {
+			case test.validationErrorCode > 0:
+				var validationErr *validationerror.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, test.validationErrorCode, validationErr.Code)
+
+			case test.serverError:
+				var serverErr *servererror.ServerError
+				require.ErrorAs(t, err, &serverErr)
+
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "usr-1", user.UserID)
+			}
+
+			assert.Equal(t, test.requests, atomic.LoadInt64(&backendAPI.requests))
+		})
+	}
+}
+
+func TestValidateTokenStrict_Cache(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	sessionToken := generateJWTWithClaims(jwt.MapClaims{
+		"iss": "https://pro-1.frontendapi.cloud.corbado.io",
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"sub": "usr-1",
+		"sid": "lse-1",
+	}, validPrivateKey, jwt.SigningMethodRS256)
+
+	backendAPI := &backendAPIMock{}
+	backendAPI.set("active", "active", false)
+	sessionSvc := newStrictSession(t, backendAPI, time.Hour)
+
+	for j := 0; j < 3; j++ {
+		_, err := sessionSvc.ValidateTokenStrict(context.Background(), sessionToken)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(2), atomic.LoadInt64(&backendAPI.requests))
+
+	// Errors are not cached
+	backendAPI = &backendAPIMock{}
+	backendAPI.set("", "", true)
+	sessionSvc = newStrictSession(t, backendAPI, time.Hour)
+
+	for j := 0; j < 3; j++ {
+		_, err := sessionSvc.ValidateTokenStrict(context.Background(), sessionToken)
+		require.Error(t, err)
+	}
+
+	assert.Equal(t, int64(3), atomic.LoadInt64(&backendAPI.requests))
+}
+
+func TestValidateTokenStrict_CacheBuildsErrorPerToken(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	newToken := func(exp time.Time) string {
+		return generateJWTWithClaims(jwt.MapClaims{
+			"iss": "https://pro-1.frontendapi.cloud.corbado.io",
+			"exp": exp.Unix(),
+			"sub": "usr-1",
+			"sid": "lse-1",
+		}, validPrivateKey, jwt.SigningMethodRS256)
+	}
+
+	backendAPI := &backendAPIMock{}
+	backendAPI.set("disabled", "active", false)
+	sessionSvc := newStrictSession(t, backendAPI, time.Hour)
+
+	first := newToken(time.Now().Add(time.Minute))
+	_, err = sessionSvc.ValidateTokenStrict(context.Background(), first)
+	require.Error(t, err)
+
+	// Cached status of the same user and long session, error must describe the second token
+	secondExp := time.Now().Add(2 * time.Minute)
+	second := newToken(secondExp)
+	_, err = sessionSvc.ValidateTokenStrict(context.Background(), second)
+
+	var validationErr *validationerror.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, validationerror.CodeUserNotActive, validationErr.Code)
+	assert.Equal(t, validationerror.Fingerprint(second), validationErr.TokenFingerprint)
+	assert.Equal(t, secondExp.Unix(), validationErr.ExpiresAt.Unix())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&backendAPI.requests))
+}
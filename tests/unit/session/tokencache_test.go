@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/denylist"
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+func TestValidateToken_TokenCache(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	now := time.Now()
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return now
+	}
+
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:      "pro-1",
+		JWKS:           readJWKS(t),
+		Clock:          clock,
+		TokenCacheSize: 1,
+	})
+
+	issuer := "https://pro-1.frontendapi.cloud.corbado.io"
+	sessionToken := generateJWT(issuer, now.Add(time.Minute).Unix(), now.Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	// Miss, then hit
+	user, err := sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+
+	cachedUser, err := sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, user, cachedUser)
+	assert.NotSame(t, user.Claims, cachedUser.Claims)
+
+	stats := sessionSvc.TokenCacheStats()
+	assert.Equal(t, entities.TokenCacheStats{Capacity: 1, Size: 1, Hits: 1, Misses: 1}, stats)
+	assert.Equal(t, 0.5, stats.HitRate())
+
+	// Custom claims and validators bypass the cache
+	_, err = sessionSvc.ValidateTokenWithClaims(context.Background(), sessionToken, &customClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), sessionSvc.TokenCacheStats().Hits)
+
+	// Least recently used token is evicted
+	otherSessionToken := generateJWT(issuer, now.Add(2*time.Minute).Unix(), now.Unix(), validPrivateKey, jwt.SigningMethodRS256)
+	_, err = sessionSvc.ValidateToken(otherSessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), sessionSvc.TokenCacheStats().Evictions)
+
+	// Cached tokens expire with exp
+	mu.Lock()
+	now = now.Add(3 * time.Minute)
+	mu.Unlock()
+
+	_, err = sessionSvc.ValidateToken(otherSessionToken)
+	var validationErr *validationerror.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, validationerror.CodeJWTExpired, validationErr.Code)
+	assert.Equal(t, 0, sessionSvc.TokenCacheStats().Size)
+}
+
+func TestValidateToken_TokenCacheJWKSChange(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, readJWKS(t), 0o600))
+
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:            "pro-1",
+		JWKSFile:             jwksFile,
+		JWKSFilePollInterval: 10 * time.Millisecond,
+		TokenCacheSize:       10,
+	})
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sessionSvc.TokenCacheStats().Size)
+
+	// Key has been removed (e.g. rotated out because it was compromised), cached token must not be accepted
+	require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys":[]}`), 0o600))
+
+	require.Eventually(t, func() bool {
+		_, err := sessionSvc.ValidateToken(sessionToken)
+
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// hookStore calls hook for every denylist lookup (and never revokes)
+type hookStore struct {
+	hook func()
+}
+
+func (h *hookStore) IsRevoked(_ context.Context, _ *denylist.Token) (bool, error) {
+	h.hook()
+
+	return false, nil
+}
+
+func TestValidateToken_TokenCacheJWKSChangeDuringValidation(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, readJWKS(t), 0o600))
+
+	var sessionSvc *session.Impl
+	var once sync.Once
+
+	// The JWKS changes after the token has been parsed but before it is added to the cache
+	store := &hookStore{hook: func() {
+		once.Do(func() {
+			require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys":[]}`), 0o600))
+
+			require.Eventually(t, func() bool {
+				return len(sessionSvc.KeySetStatus().Keys) == 0
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}}
+
+	sessionSvc = newSessionWithConfig(t, &session.Config{
+		ProjectID:            "pro-1",
+		JWKSFile:             jwksFile,
+		JWKSFilePollInterval: 10 * time.Millisecond,
+		TokenCacheSize:       10,
+		Denylist:             store,
+	})
+
+	sessionToken := generateJWT("https://pro-1.frontendapi.cloud.corbado.io", time.Now().Add(time.Minute).Unix(), time.Now().Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sessionSvc.TokenCacheStats().Size)
+
+	// Token is validated against the current JWKS (which does not contain the key anymore)
+	_, err = sessionSvc.ValidateToken(sessionToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/corbado/corbado-go/v2/internal/services/session"
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
+)
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+
+	return attributes
+}
+
+func TestValidateToken_Tracing(t *testing.T) {
+	validPrivateKey, err := generatePrivateKey("validPrivateKey.pem")
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	server, _ := newJWKSServer(t, 0)
+	sessionSvc := newSessionWithConfig(t, &session.Config{
+		ProjectID:      "pro-1",
+		JwksURI:        server.URL,
+		TracerProvider: provider,
+	})
+
+	issuer := "https://pro-1.frontendapi.cloud.corbado.io"
+	validToken := generateJWT(issuer, time.Now().Add(time.Hour).Unix(), time.Now().Add(-time.Minute).Unix(), validPrivateKey, jwt.SigningMethodRS256)
+
+	// Span of the caller is the parent
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+
+	_, err = sessionSvc.ValidateTokenWithContext(ctx, validToken)
+	require.NoError(t, err)
+
+	_, err = sessionSvc.ValidateTokenWithContext(ctx, "invalid")
+	require.Error(t, err)
+
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	valid := spans[0]
+	assert.Equal(t, "ValidateToken", valid.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), valid.Parent.SpanID())
+	assert.Equal(t, codes.Unset, valid.Status.Code)
+	assert.Equal(t, "pro-1", spanAttributes(valid)[tracing.AttributeProjectID].AsString())
+
+	invalid := spans[1]
+	assert.Equal(t, "ValidateToken", invalid.Name)
+	assert.Equal(t, codes.Error, invalid.Status.Code)
+	assert.Equal(t, "jwt_invalid_data", spanAttributes(invalid)[tracing.AttributeValidationCode].AsString())
+}
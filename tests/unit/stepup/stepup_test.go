@@ -0,0 +1,98 @@
+package stepup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/entities"
+	"github.com/corbado/corbado-go/v2/pkg/stepup"
+)
+
+type sessionValidatorMock struct {
+	user *entities.User
+}
+
+func (s *sessionValidatorMock) ValidateTokenWithContext(_ context.Context, _ string) (*entities.User, error) {
+	return s.user, nil
+}
+
+func TestRequire(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		user        *entities.User
+		reauthError bool
+	}{
+		{
+			name: "Recent authentication (iat)",
+			user: &entities.User{IssuedAt: now.Add(-time.Minute), Claims: &entities.Claims{}},
+		},
+		{
+			name:        "Old authentication (iat)",
+			user:        &entities.User{IssuedAt: now.Add(-time.Hour), Claims: &entities.Claims{}},
+			reauthError: true,
+		},
+		{
+			name: "Recent authentication (auth_time)",
+			user: &entities.User{
+				IssuedAt: now.Add(-time.Hour),
+				Claims:   &entities.Claims{AuthTime: jwt.NewNumericDate(now.Add(-time.Minute))},
+			},
+		},
+		{
+			name: "Old authentication (auth_time) in new token",
+			user: &entities.User{
+				IssuedAt: now,
+				Claims:   &entities.Claims{AuthTime: jwt.NewNumericDate(now.Add(-time.Hour))},
+			},
+			reauthError: true,
+		},
+		{
+			name:        "Unknown authentication time",
+			user:        &entities.User{},
+			reauthError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := stepup.Require(test.user, 5*time.Minute, now)
+			if !test.reauthError {
+				require.NoError(t, err)
+
+				return
+			}
+
+			reauthErr := stepup.AsReauthenticationRequiredError(err)
+			require.NotNil(t, reauthErr)
+			assert.Equal(t, 5*time.Minute, reauthErr.MaxAge)
+			assert.Contains(t, err.Error(), stepup.ErrorCode)
+		})
+	}
+}
+
+func TestValidator_ValidateToken(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := issuedAt.Add(2 * time.Minute)
+
+	validator, err := stepup.NewValidator(
+		&sessionValidatorMock{user: &entities.User{UserID: "usr-1", IssuedAt: issuedAt}},
+		stepup.WithClock(func() time.Time { return now }),
+	)
+	require.NoError(t, err)
+
+	user, err := validator.ValidateToken(context.Background(), "token", 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "usr-1", user.UserID)
+
+	_, err = validator.ValidateToken(context.Background(), "token", time.Minute)
+	reauthErr := stepup.AsReauthenticationRequiredError(err)
+	require.NotNil(t, reauthErr)
+	assert.Equal(t, issuedAt, reauthErr.AuthenticatedAt)
+}
@@ -0,0 +1,137 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/corbado/corbado-go/v2/pkg/tracing"
+)
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+
+	return attributes
+}
+
+func TestClient_Do(t *testing.T) {
+	var traceparent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		if r.URL.Path == "/v2/users/usr-unknown" {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		_, _ = w.Write([]byte(`{"httpStatusCode":200,"requestData":{"requestID":"req-123","link":""}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	client, err := tracing.NewClient(http.DefaultClient, tracing.NewTracer(provider), tracing.NewPropagator(propagation.TraceContext{}))
+	require.NoError(t, err)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+
+	req, err := http.NewRequestWithContext(tracing.WithOperation(ctx, "UserGet"), http.MethodGet, server.URL+"/v2/users/usr-1?filter[]=identifierValue:eq:jane@example.com", nil)
+	require.NoError(t, err)
+
+	rsp, err := client.Do(req)
+	require.NoError(t, err)
+
+	// Body can still be read by the generated client
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "req-123")
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v2/users/usr-unknown", nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	require.NoError(t, err)
+
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	success := spans[0]
+	attributes := spanAttributes(success)
+	assert.Equal(t, "UserGet", success.Name)
+	assert.Equal(t, trace.SpanKindClient, success.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), success.Parent.SpanID())
+	assert.Equal(t, codes.Unset, success.Status.Code)
+	assert.Equal(t, "UserGet", attributes[tracing.AttributeOperation].AsString())
+	assert.Equal(t, "req-123", attributes[tracing.AttributeRequestID].AsString())
+	assert.Equal(t, int64(http.StatusOK), attributes["http.status_code"].AsInt64())
+	assert.Equal(t, http.MethodGet, attributes["http.method"].AsString())
+	assert.Equal(t, server.URL+"/v2/users/usr-1", attributes["http.url"].AsString())
+
+	failure := spans[1]
+	assert.Equal(t, "HTTP GET", failure.Name)
+	assert.Equal(t, codes.Error, failure.Status.Code)
+	assert.Equal(t, int64(http.StatusNotFound), spanAttributes(failure)["http.status_code"].AsInt64())
+
+	// Trace context has been propagated (of the last request)
+	propagated := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier{"Traceparent": []string{traceparent}})
+	assert.Equal(t, failure.SpanContext.TraceID(), trace.SpanContextFromContext(propagated).TraceID())
+	assert.Equal(t, failure.SpanContext.SpanID(), trace.SpanContextFromContext(propagated).SpanID())
+}
+
+func TestClient_Do_NetworkError(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	client, err := tracing.NewClient(http.DefaultClient, tracing.NewTracer(provider), tracing.NewPropagator(nil))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, server.URL+"/v2/users/usr-1", nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	require.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+}
+
+func TestNewPropagator(t *testing.T) {
+	// Defaults to the global propagator
+	previous := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(previous)
+	})
+
+	otel.SetTextMapPropagator(propagation.Baggage{})
+	assert.Equal(t, propagation.Baggage{}.Fields(), tracing.NewPropagator(nil).Fields())
+
+	assert.Equal(t, propagation.TraceContext{}, tracing.NewPropagator(propagation.TraceContext{}))
+}
@@ -0,0 +1,65 @@
+package validationerror
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/corbado/corbado-go/v2/pkg/validationerror"
+)
+
+func TestCode_String(t *testing.T) {
+	assert.Equal(t, "jwt_expired", validationerror.CodeJWTExpired.String())
+	assert.Equal(t, "long_session_not_active", validationerror.CodeLongSessionNotActive.String())
+	assert.Equal(t, "code(999)", validationerror.Code(999).String())
+}
+
+func TestCode_JSON(t *testing.T) {
+	data, err := json.Marshal(validationerror.CodeJWTUnknownKey)
+	require.NoError(t, err)
+	assert.Equal(t, `"jwt_unknown_key"`, string(data))
+
+	var code validationerror.Code
+	require.NoError(t, json.Unmarshal(data, &code))
+	assert.Equal(t, validationerror.CodeJWTUnknownKey, code)
+
+	assert.Error(t, json.Unmarshal([]byte(`"unknown"`), &code))
+}
+
+func TestValidationError_Is(t *testing.T) {
+	err := errors.WithStack(validationerror.Wrap(context.DeadlineExceeded, "timeout", validationerror.CodeTimeout))
+
+	assert.ErrorIs(t, err, validationerror.ErrTimeout)
+	assert.NotErrorIs(t, err, validationerror.ErrJWTExpired)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.NotErrorIs(t, validationerror.New("expired", validationerror.CodeJWTExpired), context.DeadlineExceeded)
+}
+
+func TestValidationError_MarshalJSON(t *testing.T) {
+	validationErr := validationerror.New("JWT validation failed: 'expired'", validationerror.CodeJWTExpired)
+	validationErr.Reason = "expired"
+	validationErr.KeyID = "kid123"
+	validationErr.TokenFingerprint = validationerror.Fingerprint("token")
+	validationErr.Token = "token"
+
+	data, err := json.Marshal(validationErr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"message": "JWT validation failed: 'expired'",
+		"code": "jwt_expired",
+		"reason": "expired",
+		"kid": "kid123",
+		"tokenFingerprint": "sha256:3c469e9d6c5875d3"
+	}`, string(data))
+
+	validationErr.ExpiresAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data, err = json.Marshal(validationErr)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"expiresAt":"2024-01-01T00:00:00Z"`)
+}